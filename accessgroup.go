@@ -0,0 +1,12 @@
+package keyring
+
+// AccessGroupLister is implemented by backends that can report which keychain
+// access groups hold items for the service.
+//
+// This is an administrative aid for diagnosing items that landed in the wrong
+// group. The results only cover the groups the current process is entitled to
+// see, so an empty result does not prove that no other groups exist.
+type AccessGroupLister interface {
+	// Returns the distinct access groups of the service's items
+	AccessGroups() ([]string, error)
+}
@@ -1,54 +1,279 @@
 package keyring
 
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
 // ArrayKeyring is a mock/non-secure backend that meets the Keyring interface.
 // It is intended to be used to aid unit testing of code that relies on the package.
 // NOTE: Do not use in production code.
 type ArrayKeyring struct {
 	items map[string]Item
+	opts  ArrayOptions
+	aead  cipher.AEAD
+}
+
+// ArrayOptions makes an ArrayKeyring emulate the stricter semantics of a real
+// backend. The zero value is a permissive upsert with case-sensitive keys.
+type ArrayOptions struct {
+	// CaseInsensitiveKeys treats keys differing only in case as the same key, like wincred
+	CaseInsensitiveKeys bool
+
+	// CreateOnly makes Set return ErrKeyExists for existing keys instead of replacing them
+	CreateOnly bool
+
+	// MaxValueSize makes Set return ErrValueTooLarge for longer data, 0 means unbounded
+	MaxValueSize int
+
+	// EncryptInMemory keeps Item.Data encrypted with a random key that only lives in this
+	// ArrayKeyring, so that secrets don't show up as plaintext in heap dumps or swap. Get
+	// decrypts into a new buffer for the caller. It doesn't protect against anyone who can
+	// read the process's memory at will, as the key is there too
+	EncryptInMemory bool
+
+	// ConstantTimeLookups makes Get compare the key against every stored key in constant time
+	// instead of looking it up in a map, so that it takes about as long whether the key exists
+	// or not. This is a mitigation, not a guarantee: the length of the key and the number of
+	// items still show, and Get costs time proportional to the number of items
+	ConstantTimeLookups bool
 }
 
 // NewArrayKeyring returns an ArrayKeyring, optionally constructed with an initial slice
 // of items.
 func NewArrayKeyring(initial []Item) *ArrayKeyring {
-	kr := &ArrayKeyring{}
+	return NewArrayKeyringWithOptions(initial, ArrayOptions{})
+}
+
+// NewArrayKeyringWithOptions returns an ArrayKeyring that behaves as described
+// by opts, optionally constructed with an initial slice of items. Initial items
+// rejected by opts are dropped.
+func NewArrayKeyringWithOptions(initial []Item, opts ArrayOptions) *ArrayKeyring {
+	kr := &ArrayKeyring{opts: opts}
 	for _, i := range initial {
 		_ = kr.Set(i)
 	}
 	return kr
 }
 
+// mapKey returns the key items are stored under in the map.
+func (k *ArrayKeyring) mapKey(key string) string {
+	if k.opts.CaseInsensitiveKeys {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
 // Get returns an Item matching Key.
 func (k *ArrayKeyring) Get(key string) (Item, error) {
-	if i, ok := k.items[key]; ok {
-		return i, nil
+	if k.opts.ConstantTimeLookups {
+		return k.getConstantTime(key)
+	}
+	if i, ok := k.items[k.mapKey(key)]; ok {
+		return k.decrypt(i)
 	}
 	return Item{}, ErrKeyNotFound
 }
 
+// getConstantTime compares key with every stored key without stopping at a
+// match, see ArrayOptions.ConstantTimeLookups.
+func (k *ArrayKeyring) getConstantTime(key string) (Item, error) {
+	wanted := []byte(k.mapKey(key))
+	var found Item
+	matched := 0
+	for mapKey, i := range k.items {
+		if subtle.ConstantTimeCompare(wanted, []byte(mapKey)) == 1 {
+			found = i
+			matched = 1
+		}
+	}
+	if matched == 0 {
+		return Item{}, ErrKeyNotFound
+	}
+	return k.decrypt(found)
+}
+
+// GetWithCallback lends fn a copy of the stored data, unless it is encrypted
+// in memory and Get already decrypted it into a new buffer, and wipes it.
+func (k *ArrayKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	item, err := k.Get(key)
+	if err != nil {
+		return err
+	}
+
+	data := item.Data
+	if !k.opts.EncryptInMemory {
+		data = append([]byte(nil), data...)
+	}
+	defer wipe(data)
+	return fn(data)
+}
+
+// encrypt returns i with its Data sealed by the in-memory key, if EncryptInMemory is set.
+func (k *ArrayKeyring) encrypt(i Item) (Item, error) {
+	if !k.opts.EncryptInMemory {
+		return i, nil
+	}
+
+	if k.aead == nil {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return Item{}, err
+		}
+		block, err := aes.NewCipher(key)
+		for n := range key {
+			key[n] = 0
+		}
+		if err != nil {
+			return Item{}, err
+		}
+		if k.aead, err = cipher.NewGCM(block); err != nil {
+			return Item{}, err
+		}
+	}
+
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Item{}, err
+	}
+	i.Data = k.aead.Seal(nonce, nonce, i.Data, nil)
+	return i, nil
+}
+
+// decrypt reverses encrypt, returning a copy of i with its plaintext Data.
+func (k *ArrayKeyring) decrypt(i Item) (Item, error) {
+	if !k.opts.EncryptInMemory {
+		return i, nil
+	}
+
+	size := k.aead.NonceSize()
+	if len(i.Data) < size {
+		return Item{}, errors.New("encrypted item data is too short")
+	}
+	data, err := k.aead.Open(nil, i.Data[:size], i.Data[size:], nil)
+	if err != nil {
+		return Item{}, err
+	}
+	i.Data = data
+	return i, nil
+}
+
 // Set will store an item on the mock Keyring.
 func (k *ArrayKeyring) Set(i Item) error {
+	return k.SetWithOptions(i)
+}
+
+// SetWithOptions will store an item on the mock Keyring, as modified by opts.
+func (k *ArrayKeyring) SetWithOptions(i Item, opts ...SetOption) error {
+	_, err := k.set(i, newSetOptions(opts))
+	return err
+}
+
+// SetResult will store an item on the mock Keyring and report whether the key was already there.
+func (k *ArrayKeyring) SetResult(i Item) (SetOutcome, error) {
+	return k.set(i, setOptions{})
+}
+
+func (k *ArrayKeyring) set(i Item, o setOptions) (SetOutcome, error) {
+	if err := checkValueSize(i, k.opts.MaxValueSize); err != nil {
+		return 0, err
+	}
 	if k.items == nil {
 		k.items = map[string]Item{}
 	}
-	k.items[i.Key] = i
-	return nil
+	outcome := SetCreated
+	if _, ok := k.items[k.mapKey(i.Key)]; ok {
+		if o.createOnly || k.opts.CreateOnly {
+			return 0, ErrKeyExists
+		}
+		outcome = SetUpdated
+	}
+	i, err := k.encrypt(i)
+	if err != nil {
+		return 0, err
+	}
+	k.items[k.mapKey(i.Key)] = i
+	return outcome, nil
 }
 
 // Remove will delete an Item from the Keyring.
 func (k *ArrayKeyring) Remove(key string) error {
-	delete(k.items, key)
+	delete(k.items, k.mapKey(key))
 	return nil
 }
 
 // Keys provides a slice of all Item keys on the Keyring.
 func (k *ArrayKeyring) Keys() ([]string, error) {
 	var keys = []string{}
-	for key := range k.items {
-		keys = append(keys, key)
+	for _, i := range k.items {
+		keys = append(keys, i.Key)
 	}
 	return keys, nil
 }
 
+// MaxValueSize returns the limit set by ArrayOptions.MaxValueSize.
+func (k *ArrayKeyring) MaxValueSize() int {
+	return k.opts.MaxValueSize
+}
+
 func (k *ArrayKeyring) GetMetadata(_ string) (Metadata, error) {
 	return Metadata{}, ErrMetadataNeedsCredentials
 }
+
+// RemoveAll deletes every Item from the Keyring.
+func (k *ArrayKeyring) RemoveAll() error {
+	k.items = nil
+	return nil
+}
+
+// Reset deletes every Item from the Keyring.
+func (k *ArrayKeyring) Reset() error {
+	return k.RemoveAll()
+}
+
+// RemoveMatching deletes every Item whose key satisfies pred.
+func (k *ArrayKeyring) RemoveMatching(pred func(key string) bool) (int, error) {
+	removed := 0
+	for key, i := range k.items {
+		if pred(i.Key) {
+			delete(k.items, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Save writes every Item on the Keyring to w as JSON, ordered by key, so that it
+// can be restored with LoadArrayKeyring.
+// NOTE: The output is not encrypted. Use the file backend to persist real secrets.
+func (k *ArrayKeyring) Save(w io.Writer) error {
+	items := make([]Item, 0, len(k.items))
+	for _, i := range k.items {
+		i, err := k.decrypt(i)
+		if err != nil {
+			return err
+		}
+		items = append(items, i)
+	}
+	sort.Slice(items, func(a, b int) bool { return items[a].Key < items[b].Key })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// LoadArrayKeyring returns an ArrayKeyring populated from JSON written by Save.
+func LoadArrayKeyring(r io.Reader) (*ArrayKeyring, error) {
+	var items []Item
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, err
+	}
+	return NewArrayKeyring(items), nil
+}
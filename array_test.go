@@ -1,6 +1,11 @@
 package keyring
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
 
 func TestArrayKeyringSetWhenEmpty(t *testing.T) {
 	k := &ArrayKeyring{}
@@ -23,3 +28,134 @@ func TestArrayKeyringSetWhenEmpty(t *testing.T) {
 		t.Fatalf("Key wasn't persisted: %q", foundItem.Key)
 	}
 }
+
+func TestArrayKeyringSaveAndLoad(t *testing.T) {
+	k := NewArrayKeyring([]Item{
+		{Key: "llamas", Data: []byte("llamas are great"), Label: "Llamas"},
+		{Key: "alpacas", Data: []byte("alpacas are great")},
+	})
+
+	var buf bytes.Buffer
+	if err := k.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadArrayKeyring(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foundItem, err := loaded.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(foundItem.Data) != "llamas are great" || foundItem.Label != "Llamas" {
+		t.Fatalf("Loaded item differs from saved item: %#v", foundItem)
+	}
+
+	keys, _ := loaded.Keys()
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %v", keys)
+	}
+}
+
+func TestArrayKeyringOptions(t *testing.T) {
+	k := NewArrayKeyringWithOptions(nil, ArrayOptions{
+		CaseInsensitiveKeys: true,
+		CreateOnly:          true,
+		MaxValueSize:        16,
+	})
+
+	if err := k.Set(Item{Key: "Llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := k.Get("LLAMAS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Key != "Llamas" {
+		t.Fatalf("Expected the stored key to keep its case, got %q", item.Key)
+	}
+
+	if err = k.Set(Item{Key: "llamas", Data: []byte("llamas are ok")}); err != ErrKeyExists {
+		t.Fatalf("Expected ErrKeyExists, got %v", err)
+	}
+
+	if err = k.Set(Item{Key: "alpacas", Data: []byte("alpacas are even better")}); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge, got %v", err)
+	}
+	if MaxValueSize(k) != 16 {
+		t.Fatalf("Expected MaxValueSize 16, got %d", MaxValueSize(k))
+	}
+}
+
+func TestArrayKeyringEncryptInMemory(t *testing.T) {
+	k := NewArrayKeyringWithOptions([]Item{{Key: "llamas", Data: []byte("llamas are great")}}, ArrayOptions{EncryptInMemory: true})
+
+	if bytes.Contains(k.items["llamas"].Data, []byte("llamas are great")) {
+		t.Fatal("Expected the stored data to be encrypted")
+	}
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected data %q", item.Data)
+	}
+
+	var buf bytes.Buffer
+	if err := k.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadArrayKeyring(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item, _ := loaded.Get("llamas"); string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected data after Save %q", item.Data)
+	}
+}
+
+func TestArrayKeyringConstantTimeLookups(t *testing.T) {
+	k := NewArrayKeyringWithOptions(nil, ArrayOptions{ConstantTimeLookups: true, CaseInsensitiveKeys: true})
+	for _, key := range []string{"llamas", "alpacas", "vicunas"} {
+		if err := k.Set(Item{Key: key, Data: []byte(key + " are great")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	item, err := k.Get("ALPACAS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "alpacas are great" {
+		t.Fatalf("Get returned %q", item.Data)
+	}
+
+	if _, err := k.Get("guanacos"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+// BenchmarkArrayKeyringGet compares Get for present and missing keys, with
+// and without ConstantTimeLookups.
+func BenchmarkArrayKeyringGet(b *testing.B) {
+	for _, constantTime := range []bool{false, true} {
+		k := NewArrayKeyringWithOptions(nil, ArrayOptions{ConstantTimeLookups: constantTime})
+		for i := 0; i < 100; i++ {
+			if err := k.Set(Item{Key: fmt.Sprintf("key-%03d", i), Data: []byte("secret")}); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		for _, key := range []string{"key-050", "key-999"} {
+			b.Run(fmt.Sprintf("constantTime=%v/key=%s", constantTime, key), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = k.Get(key)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package keyring
+
+// BackendTyper is implemented by every backend to report its BackendType.
+// Backends registered with RegisterBackend can implement it too.
+type BackendTyper interface {
+	// Returns the type the backend is registered as
+	BackendType() BackendType
+}
+
+// BackendTypeOf returns the type of the backend behind k, unwrapping the
+// decorators that Open and the New functions add, e.g. to warn that secrets
+// are only protected by the file backend or to enable keychain-only features
+// after Open fell back. It returns InvalidBackend for an ArrayKeyring, for a
+// Keyring combining several backends, such as one from NewTiered, and for
+// backends that don't implement BackendTyper.
+func BackendTypeOf(k Keyring) BackendType {
+	if t, ok := as[BackendTyper](k); ok {
+		return t.BackendType()
+	}
+	return InvalidBackend
+}
@@ -0,0 +1,27 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackendTypeOf(t *testing.T) {
+	file := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+
+	for name, k := range map[string]Keyring{
+		"backend":          file,
+		"decorated":        &caseInsensitiveKeyring{forwarding{&observingKeyring{file, FileBackend, nil}}},
+		"wrapped by a New": NewTimeout(NewChunked(file, 0), time.Second),
+	} {
+		if got := BackendTypeOf(k); got != FileBackend {
+			t.Fatalf("Expected %s for the %s keyring, got %q", FileBackend, name, got)
+		}
+	}
+
+	if got := BackendTypeOf(NewArrayKeyring(nil)); got != InvalidBackend {
+		t.Fatalf("Expected InvalidBackend for an ArrayKeyring, got %q", got)
+	}
+	if got := BackendTypeOf(NewTiered(NewArrayKeyring(nil), file)); got != InvalidBackend {
+		t.Fatalf("Expected InvalidBackend for a tiered keyring, got %q", got)
+	}
+}
@@ -0,0 +1,34 @@
+package keyring
+
+import "errors"
+
+// BatchResult holds the outcome of GetBatch.
+type BatchResult struct {
+	// Items found, by key
+	Items map[string]Item
+	// Keys that aren't on the keyring
+	NotFound []string
+}
+
+// GetBatch reads the items with the given keys. Missing keys are reported in
+// the result's NotFound rather than failing the batch; any other error stops
+// the batch and is returned with the items read so far.
+//
+// The keys are read one at a time with Get. That prompts at most once for the
+// file backend, which caches the passphrase after the first read, but the
+// keychain checks each item's access control separately: the pinned
+// go-keychain has no authentication context to share between reads.
+func GetBatch(kr Keyring, keys []string) (BatchResult, error) {
+	result := BatchResult{Items: make(map[string]Item, len(keys))}
+	for _, key := range keys {
+		item, err := kr.Get(key)
+		if errors.Is(err, ErrKeyNotFound) {
+			result.NotFound = append(result.NotFound, key)
+			continue
+		} else if err != nil {
+			return result, err
+		}
+		result.Items[key] = item
+	}
+	return result, nil
+}
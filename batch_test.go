@@ -0,0 +1,67 @@
+package keyring
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestGetBatch(t *testing.T) {
+	prompts := 0
+	k := &fileKeyring{
+		dir: t.TempDir(),
+		passwordFunc: func(string) (string, error) {
+			prompts++
+			return "no more secrets", nil
+		},
+	}
+
+	for _, item := range []Item{
+		{Key: "access-token", Data: []byte("llamas")},
+		{Key: "refresh-token", Data: []byte("alpacas")},
+	} {
+		if err := k.Set(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := GetBatch(k, []string{"access-token", "expiry", "refresh-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Items) != 2 || string(result.Items["refresh-token"].Data) != "alpacas" {
+		t.Fatalf("Unexpected items %v", result.Items)
+	}
+	if !reflect.DeepEqual(result.NotFound, []string{"expiry"}) {
+		t.Fatalf("Unexpected missing keys %v", result.NotFound)
+	}
+	if prompts != 1 {
+		t.Fatalf("Expected 1 prompt, got %d", prompts)
+	}
+}
+
+// wrappingKeyring wraps the errors of Get, as decorators may.
+type wrappingKeyring struct {
+	*ArrayKeyring
+}
+
+func (k wrappingKeyring) Get(key string) (Item, error) {
+	item, err := k.ArrayKeyring.Get(key)
+	if err != nil {
+		return Item{}, fmt.Errorf("reading %s: %w", key, err)
+	}
+	return item, nil
+}
+
+func TestGetBatchWrappedNotFound(t *testing.T) {
+	k := wrappingKeyring{NewArrayKeyring([]Item{{Key: "access-token", Data: []byte("llamas")}})}
+
+	result, err := GetBatch(k, []string{"access-token", "expiry"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 || !reflect.DeepEqual(result.NotFound, []string{"expiry"}) {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,80 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BulkRemover is implemented by backends that can remove many items more
+// efficiently than calling Remove once per key.
+type BulkRemover interface {
+	// Removes every item stored on the keyring
+	RemoveAll() error
+	// Removes every item whose key satisfies pred, returning how many were removed
+	RemoveMatching(pred func(key string) bool) (int, error)
+}
+
+// RemoveError aggregates the per-key failures of a bulk removal.
+type RemoveError map[string]error
+
+func (e RemoveError) Error() string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	msgs := make([]string, len(keys))
+	for i, key := range keys {
+		msgs[i] = fmt.Sprintf("%s: %v", key, e[key])
+	}
+	return fmt.Sprintf("failed to remove %d items: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// RemoveAll removes every item from the keyring, using the backend's bulk
+// removal when it has one.
+func RemoveAll(kr Keyring) error {
+	if br, ok := as[BulkRemover](kr); ok {
+		return br.RemoveAll()
+	}
+	_, err := removeMatching(kr, func(string) bool { return true })
+	return err
+}
+
+// RemoveMatching removes every item whose key satisfies pred and returns the
+// number of items removed. Failures for individual keys are collected into a
+// RemoveError rather than aborting the remaining removals.
+func RemoveMatching(kr Keyring, pred func(key string) bool) (int, error) {
+	if br, ok := as[BulkRemover](kr); ok {
+		return br.RemoveMatching(pred)
+	}
+	return removeMatching(kr, pred)
+}
+
+// removeMatching is the generic Keys and Remove based implementation.
+func removeMatching(kr Keyring, pred func(key string) bool) (int, error) {
+	keys, err := kr.Keys()
+	if err != nil && !errors.Is(err, ErrPartialResults) {
+		return 0, err
+	}
+
+	removed := 0
+	errs := RemoveError{}
+	for _, key := range keys {
+		if !pred(key) {
+			continue
+		}
+		if err := kr.Remove(key); err != nil {
+			errs[key] = err
+			continue
+		}
+		removed++
+	}
+
+	if len(errs) > 0 {
+		return removed, errs
+	}
+	return removed, nil
+}
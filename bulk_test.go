@@ -0,0 +1,102 @@
+package keyring
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRemoveMatchingArrayKeyring(t *testing.T) {
+	k := NewArrayKeyring([]Item{
+		{Key: "aws/default"},
+		{Key: "aws/prod"},
+		{Key: "gcp/default"},
+	})
+
+	removed, err := RemoveMatching(k, func(key string) bool {
+		return strings.HasPrefix(key, "aws/")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Fatalf("Expected 2 items removed, got %d", removed)
+	}
+
+	keys, _ := k.Keys()
+	if len(keys) != 1 || keys[0] != "gcp/default" {
+		t.Fatalf("Unexpected keys remaining: %v", keys)
+	}
+
+	if err := RemoveAll(k); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ = k.Keys()
+	if len(keys) != 0 {
+		t.Fatalf("Expected no keys, got %v", keys)
+	}
+}
+
+func TestRemoveAllFileKeyring(t *testing.T) {
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	for _, key := range []string{"llamas", "alpacas"} {
+		if err := k.Set(Item{Key: key, Data: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := RemoveAll(k); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("Expected no keys, got %v", keys)
+	}
+}
+
+type failingRemoveKeyring struct {
+	Keyring
+	fail string
+}
+
+func (k failingRemoveKeyring) Remove(key string) error {
+	if key == k.fail {
+		return os.ErrPermission
+	}
+	return k.Keyring.Remove(key)
+}
+
+func TestRemoveMatchingCollectsErrors(t *testing.T) {
+	k := failingRemoveKeyring{
+		Keyring: NewArrayKeyring([]Item{{Key: "llamas"}, {Key: "alpacas"}, {Key: "vicunas"}}),
+		fail:    "alpacas",
+	}
+
+	removed, err := RemoveMatching(k, func(string) bool { return true })
+	if removed != 2 {
+		t.Fatalf("Expected 2 items removed, got %d", removed)
+	}
+
+	var removeErr RemoveError
+	if !errors.As(err, &removeErr) {
+		t.Fatalf("Expected a RemoveError, got %v", err)
+	}
+	if !errors.Is(removeErr["alpacas"], os.ErrPermission) {
+		t.Fatalf("Expected permission error for alpacas, got %v", removeErr)
+	}
+
+	keys, _ := k.Keys()
+	sort.Strings(keys)
+	if len(keys) != 1 || keys[0] != "alpacas" {
+		t.Fatalf("Unexpected keys remaining: %v", keys)
+	}
+}
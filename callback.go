@@ -0,0 +1,56 @@
+package keyring
+
+// CallbackGetter is implemented by Keyrings that can lend an item's data to a
+// function and wipe it afterwards.
+type CallbackGetter interface {
+	// Calls fn with the data of the item matching the key, then zeroes it
+	GetWithCallback(key string, fn func(data []byte) error) error
+}
+
+// GetWithCallback calls fn with the data of the item with the given key and
+// zeroes the data when fn returns or panics, so that the plaintext doesn't
+// outlive the call. fn must not keep data or slices of it. Returns the error
+// from Get, such as ErrKeyNotFound, or from fn.
+//
+// The backends, and the decorators that only change keys or observe
+// operations, wipe the data they decrypted. Other Keyrings, such as one from
+// NewTiered whose fast tier may hold the only copy of an item, only have a
+// copy wiped, and their own copy is left to the garbage collector as with Get.
+func GetWithCallback(kr Keyring, key string, fn func(data []byte) error) error {
+	if g, ok := kr.(CallbackGetter); ok {
+		return g.GetWithCallback(key, fn)
+	}
+
+	item, err := kr.Get(key)
+	if err != nil {
+		return err
+	}
+
+	data := append([]byte(nil), item.Data...)
+	defer wipe(data)
+	return fn(data)
+}
+
+// getWithCallback implements GetWithCallback for a get that returns data
+// nothing else refers to, wiping the data and any DataParts.
+func getWithCallback(get func(key string) (Item, error), key string, fn func(data []byte) error) error {
+	item, err := get(key)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		wipe(item.Data)
+		for _, part := range item.DataParts {
+			wipe(part)
+		}
+	}()
+	return fn(item.Data)
+}
+
+// wipe zeroes b.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,76 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetWithCallbackWipesData(t *testing.T) {
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	var lent []byte
+	err := GetWithCallback(k, "llamas", func(data []byte) error {
+		if string(data) != "llamas are great" {
+			t.Fatalf("Unexpected data %q", data)
+		}
+		lent = data
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range lent {
+		if b != 0 {
+			t.Fatalf("Expected the data to be wiped, got %q", lent)
+		}
+	}
+
+	if err := GetWithCallback(k, "alpacas", func([]byte) error { return nil }); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestGetWithCallbackWipesOnPanic(t *testing.T) {
+	k := NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}})
+
+	var lent []byte
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected the panic to propagate")
+			}
+		}()
+		_ = GetWithCallback(k, "llamas", func(data []byte) error {
+			lent = data
+			panic("oops")
+		})
+	}()
+
+	if string(lent) != string(make([]byte, len("llamas are great"))) {
+		t.Fatalf("Expected the data to be wiped, got %q", lent)
+	}
+
+	// the stored item is untouched
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Expected the stored data to be intact, got %q", item.Data)
+	}
+}
+
+func TestGetWithCallbackReturnsCallbackError(t *testing.T) {
+	errCallback := errors.New("callback failed")
+	k := NewTiered(NewArrayKeyring(nil), NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}}))
+
+	if err := GetWithCallback(k, "llamas", func([]byte) error { return errCallback }); err != errCallback {
+		t.Fatalf("Expected the callback's error, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package keyring
+
+// probeDiscarder is implemented by backends whose opener can create something,
+// such as the keyctl backend's named keyring, so that CandidateBackends can
+// remove it again.
+type probeDiscarder interface {
+	discardProbe() error
+}
+
+// probeChecker is implemented by backends whose opener succeeds without
+// checking settings that using them requires, such as the file backend's
+// FileDir, so that CandidateBackends can check them.
+type probeChecker interface {
+	checkProbe() error
+}
+
+// CandidateBackends returns the backends that Open could use with c, in the
+// order Open would try them, e.g. for a setup wizard to offer only the ones
+// whose opener succeeds with the user's paths, installed programs and
+// settings. Each of c.AllowedBackends, or of AvailableBackends if that's nil,
+// or only c.RequireBackend if set, is opened and discarded again without
+// storing anything. Anything an opener creates, such as a keyctl named
+// keyring, is removed again where possible. With PassInitIfMissing, pass is
+// only probed as it is, without running `pass init`, so it isn't listed until
+// the store exists. It is safe to call repeatedly.
+//
+// The file backend is only listed with a FileDir that is, or can become, a
+// directory, and a FilePasswordFunc. Openers that check nothing, such as the
+// keychain's, are listed whenever their platform supports them, so a listed
+// backend can still fail on first use, e.g. when the user denies access.
+func (c Config) CandidateBackends() []BackendType {
+	allowed := c.AllowedBackends
+	if c.RequireBackend != InvalidBackend {
+		allowed = []BackendType{c.RequireBackend}
+	} else if allowed == nil {
+		allowed = AvailableBackends()
+	}
+
+	probe := c
+	probe.PassInitIfMissing = false
+
+	candidates := []BackendType{}
+	for _, backend := range allowed {
+		open, ok := supportedBackends[backend]
+		if !ok {
+			continue
+		}
+
+		kr, err := open(probe)
+		if err != nil {
+			debugf("Backend %s isn't a candidate: %s", backend, err)
+			continue
+		}
+		if checker, ok := kr.(probeChecker); ok {
+			if err := checker.checkProbe(); err != nil {
+				debugf("Backend %s isn't a candidate: %s", backend, err)
+				continue
+			}
+		}
+		if d, ok := kr.(probeDiscarder); ok {
+			if err := d.discardProbe(); err != nil {
+				debugf("Failed to clean up after probing %s: %s", backend, err)
+			}
+		}
+		candidates = append(candidates, backend)
+	}
+	return candidates
+}
@@ -0,0 +1,93 @@
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// probedKeyring counts the probes CandidateBackends discards.
+type probedKeyring struct {
+	*ArrayKeyring
+	discarded *int
+}
+
+func (k probedKeyring) discardProbe() error {
+	*k.discarded++
+	return nil
+}
+
+func TestCandidateBackends(t *testing.T) {
+	const (
+		workingBackend BackendType = "working"
+		failingBackend BackendType = "failing"
+		probedBackend  BackendType = "probed"
+	)
+
+	discarded := 0
+	defer RegisterBackend(workingBackend, func(cfg Config) (Keyring, error) {
+		return NewArrayKeyring(nil), nil
+	})()
+	defer RegisterBackend(failingBackend, func(cfg Config) (Keyring, error) {
+		return nil, errors.New("not configured")
+	})()
+	defer RegisterBackend(probedBackend, func(cfg Config) (Keyring, error) {
+		if cfg.PassInitIfMissing {
+			t.Fatal("Expected probing not to initialize a pass store")
+		}
+		return probedKeyring{NewArrayKeyring(nil), &discarded}, nil
+	})()
+
+	cfg := Config{
+		AllowedBackends:   []BackendType{failingBackend, probedBackend, "unknown", workingBackend},
+		PassInitIfMissing: true,
+	}
+	for i := 1; i <= 2; i++ {
+		candidates := cfg.CandidateBackends()
+		if !reflect.DeepEqual(candidates, []BackendType{probedBackend, workingBackend}) {
+			t.Fatalf("Unexpected candidates %v", candidates)
+		}
+		if discarded != i {
+			t.Fatalf("Expected %d discarded probes, got %d", i, discarded)
+		}
+	}
+
+	cfg.RequireBackend = failingBackend
+	if candidates := cfg.CandidateBackends(); len(candidates) != 0 {
+		t.Fatalf("Expected no candidates with a failing RequireBackend, got %v", candidates)
+	}
+}
+
+func TestCandidateBackendsFile(t *testing.T) {
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "llamas")
+	if err := os.WriteFile(notDir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{"configured", Config{FileDir: dir, FilePasswordFunc: FixedStringPrompt("no more secrets")}, true},
+		{"missing dir", Config{FileDir: filepath.Join(dir, "alpacas"), FilePasswordFunc: FixedStringPrompt("no more secrets")}, true},
+		{"no dir", Config{FilePasswordFunc: FixedStringPrompt("no more secrets")}, false},
+		{"file as dir", Config{FileDir: notDir, FilePasswordFunc: FixedStringPrompt("no more secrets")}, false},
+		{"no password", Config{FileDir: dir}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.RequireBackend = FileBackend
+			got := len(tc.cfg.CandidateBackends()) == 1
+			if got != tc.want {
+				t.Fatalf("Expected the file backend to be a candidate: %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "alpacas")); !os.IsNotExist(err) {
+		t.Fatalf("Expected probing not to create the directory, got %v", err)
+	}
+}
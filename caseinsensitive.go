@@ -0,0 +1,137 @@
+package keyring
+
+import "strings"
+
+// caseInsensitiveKeyring lower cases every key before it reaches the backend,
+// see Config.CaseInsensitiveKeys. It implements the optional interfaces that
+// take or return keys itself, apart from EncryptedExporter, whose import would
+// store keys as they are.
+type caseInsensitiveKeyring struct {
+	forwarding
+}
+
+func (k *caseInsensitiveKeyring) Get(key string) (Item, error) {
+	return k.Keyring.Get(strings.ToLower(key))
+}
+
+func (k *caseInsensitiveKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return GetWithCallback(k.Keyring, strings.ToLower(key), fn)
+}
+
+func (k *caseInsensitiveKeyring) GetMetadata(key string) (Metadata, error) {
+	return k.Keyring.GetMetadata(strings.ToLower(key))
+}
+
+func (k *caseInsensitiveKeyring) Set(item Item) error {
+	item.Key = strings.ToLower(item.Key)
+	return k.Keyring.Set(item)
+}
+
+func (k *caseInsensitiveKeyring) SetWithOptions(item Item, opts ...SetOption) error {
+	item.Key = strings.ToLower(item.Key)
+	return SetWithOptions(k.Keyring, item, opts...)
+}
+
+func (k *caseInsensitiveKeyring) SetResult(item Item) (SetOutcome, error) {
+	item.Key = strings.ToLower(item.Key)
+	return SetResult(k.Keyring, item)
+}
+
+func (k *caseInsensitiveKeyring) Remove(key string) error {
+	return k.Keyring.Remove(strings.ToLower(key))
+}
+
+// Keys lower cases keys written before CaseInsensitiveKeys was set too, so
+// that they can be passed back to Get. Keys that only differ in case are
+// listed once.
+func (k *caseInsensitiveKeyring) Keys() ([]string, error) {
+	keys, err := k.Keyring.Keys()
+	if keys == nil {
+		return nil, err
+	}
+	return lowerKeys(keys), err
+}
+
+// lowerKeys lower cases keys, dropping those that then repeat.
+func lowerKeys(keys []string) []string {
+	seen := map[string]bool{}
+	lowered := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		if !seen[key] {
+			seen[key] = true
+			lowered = append(lowered, key)
+		}
+	}
+	return lowered
+}
+
+func (k *caseInsensitiveKeyring) KeysByCategory(category string) ([]string, error) {
+	keys, err := KeysByCategory(k.Keyring, category)
+	if err != nil {
+		return nil, err
+	}
+	return lowerKeys(keys), nil
+}
+
+func (k *caseInsensitiveKeyring) KeysWithFlags() ([]KeyInfo, error) {
+	infos, err := KeysWithFlags(k.Keyring)
+	if infos == nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	lowered := make([]KeyInfo, 0, len(infos))
+	for _, info := range infos {
+		info.Key = strings.ToLower(info.Key)
+		if !seen[info.Key] {
+			seen[info.Key] = true
+			lowered = append(lowered, info)
+		}
+	}
+	return lowered, err
+}
+
+func (k *caseInsensitiveKeyring) Entries() ([]Metadata, error) {
+	entries, err := Entries(k.Keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, md := range entries {
+		if md.Item == nil {
+			continue
+		}
+		item := *md.Item
+		item.Key = strings.ToLower(item.Key)
+		entries[i].Item = &item
+	}
+	return entries, nil
+}
+
+func (k *caseInsensitiveKeyring) RemoveAll() error {
+	return RemoveAll(k.Keyring)
+}
+
+// RemoveMatching passes pred the lower cased keys.
+func (k *caseInsensitiveKeyring) RemoveMatching(pred func(key string) bool) (int, error) {
+	return RemoveMatching(k.Keyring, func(key string) bool {
+		return pred(strings.ToLower(key))
+	})
+}
+
+func (k *caseInsensitiveKeyring) Touch(key string) error {
+	return Touch(k.Keyring, strings.ToLower(key))
+}
+
+func (k *caseInsensitiveKeyring) Update(key string, mutate func(*Item) error) error {
+	return Update(k.Keyring, strings.ToLower(key), mutate)
+}
+
+func (k *caseInsensitiveKeyring) GetVersion(key string, n int) (Item, error) {
+	return GetVersion(k.Keyring, strings.ToLower(key), n)
+}
+
+func (k *caseInsensitiveKeyring) Versions(key string) ([]Metadata, error) {
+	return Versions(k.Keyring, strings.ToLower(key))
+}
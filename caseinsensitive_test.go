@@ -0,0 +1,97 @@
+package keyring
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	inner := NewArrayKeyring([]Item{{Key: "Alpacas", Data: []byte("alpacas are ok")}})
+	k := decorate(Config{CaseInsensitiveKeys: true}, "array", inner)
+
+	if err := k.Set(Item{Key: "mytoken", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := k.Get("MyToken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected data %q", item.Data)
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if key != "alpacas" && key != "mytoken" {
+			t.Fatalf("Expected lower case keys, got %v", keys)
+		}
+	}
+
+	if err := k.Remove("MYTOKEN"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inner.Get("mytoken"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestCaseInsensitiveKeysHelpers(t *testing.T) {
+	inner := NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great"), Category: "camelids"}})
+	k := decorate(Config{CaseInsensitiveKeys: true, Observer: &countingObserver{calls: map[string]int{}, errors: map[string]int{}}}, "array", inner)
+
+	err := Update(k, "LLAMAS", func(item *Item) error {
+		item.Data = []byte("llamas are still great")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item, _ := inner.Get("llamas"); string(item.Data) != "llamas are still great" {
+		t.Fatalf("Expected Update to reach the lower cased key, got %q", item.Data)
+	}
+	if keys, _ := inner.Keys(); len(keys) != 1 {
+		t.Fatalf("Expected Update not to store another key, got %v", keys)
+	}
+
+	if err := inner.Set(Item{Key: "Alpacas", Category: "camelids"}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := KeysByCategory(k, "camelids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"alpacas", "llamas"}) {
+		t.Fatalf("Expected lower cased keys, got %v", keys)
+	}
+
+	var seen []string
+	if _, err := RemoveMatching(k, func(key string) bool {
+		seen = append(seen, key)
+		return key == "alpacas"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(seen)
+	if !reflect.DeepEqual(seen, []string{"alpacas", "llamas"}) {
+		t.Fatalf("Expected the predicate to be passed lower cased keys, got %v", seen)
+	}
+	if _, err := inner.Get("Alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected Alpacas to be removed, got %v", err)
+	}
+}
+
+func TestCaseSensitiveKeysByDefault(t *testing.T) {
+	k := decorate(Config{}, "array", NewArrayKeyring(nil))
+	if err := k.Set(Item{Key: "mytoken"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Get("MyToken"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package keyring
+
+// CategoryLister is implemented by backends that can find the items in a
+// category without reading each item's data.
+type CategoryLister interface {
+	// Provides a slice of the keys of items whose Category matches
+	KeysByCategory(category string) ([]string, error)
+}
+
+// KeysByCategory returns the keys of the items on kr whose Category matches.
+//
+// Backends without a CategoryLister are filtered by reading every item, which
+// may prompt for credentials.
+func KeysByCategory(kr Keyring, category string) ([]string, error) {
+	if cl, ok := as[CategoryLister](kr); ok {
+		return cl.KeysByCategory(category)
+	}
+
+	keys, err := kr.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []string{}
+	for _, key := range keys {
+		item, err := kr.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if item.Category == category {
+			matched = append(matched, key)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,48 @@
+package keyring
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestKeysByCategory(t *testing.T) {
+	items := []Item{
+		{Key: "aws", Category: "work", Data: []byte("llamas")},
+		{Key: "github", Category: "work", Data: []byte("alpacas")},
+		{Key: "netflix", Category: "personal", Data: []byte("dromedaries")},
+		{Key: "legacy", Data: []byte("bactrians")},
+	}
+
+	file := &fileKeyring{
+		dir: t.TempDir(),
+		passwordFunc: func(string) (string, error) {
+			return "no more secrets", nil
+		},
+	}
+	for _, item := range items {
+		if err := file.Set(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Listing by category must not need the passphrase
+	file.password = ""
+	file.passwordFunc = func(string) (string, error) {
+		t.Fatal("KeysByCategory asked for the passphrase")
+		return "", nil
+	}
+
+	for name, k := range map[string]Keyring{
+		"array": NewArrayKeyring(items),
+		"file":  file,
+	} {
+		keys, err := KeysByCategory(k, "work")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		sort.Strings(keys)
+		if expected := []string{"aws", "github"}; !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("%s: Expected %q, got %q", name, expected, keys)
+		}
+	}
+}
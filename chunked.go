@@ -0,0 +1,304 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrInconsistentChunks is returned by Get on a Keyring from NewChunked when
+// the stored chunks don't add up to the item described by its manifest, e.g.
+// after a concurrent Set or a chunk was removed directly from the backend.
+var ErrInconsistentChunks = errors.New("The chunks of the item don't match its manifest")
+
+// A chunked item's Data is stored under the item's key as chunkManifestMagic
+// followed by the JSON encoding of a chunkManifest, and its chunks under
+// chunkKey. Items that fit in a chunk are stored as they are.
+const chunkManifestMagic = "keyring-chunk-manifest-v1\x00"
+
+type chunkManifest struct {
+	Chunks int
+	Size   int
+	SHA256 []byte
+}
+
+var chunkKeyPattern = regexp.MustCompile(`^(.*)#[0-9]+$`)
+
+func chunkKey(key string, i int) string {
+	return key + "#" + strconv.Itoa(i)
+}
+
+// chunkedKeyring splits large values across several items of the decorated
+// Keyring, see NewChunked. It implements the optional interfaces that list
+// keys or rewrite items itself, hiding the chunks, and leaves those that it
+// can't, such as Versioner and SetResulter, unsupported.
+type chunkedKeyring struct {
+	forwarding
+	chunkSize int
+}
+
+// NewChunked returns a Keyring that stores an Item.Data longer than chunkSize
+// bytes in inner as chunks under the keys "<key>#0", "<key>#1" and so on, and
+// a manifest under the key itself that Get uses to reassemble them. This lets
+// backends with a small value limit, such as wincred, store large secrets.
+// When chunkSize isn't positive, the backend's MaxValueSize is used, and
+// values aren't split when that is unknown too.
+//
+// A chunked Set or Remove isn't atomic. A Get that races with a Set returns
+// ErrInconsistentChunks rather than a mix of both values, and a Set that fails
+// part way through leaves the previous value readable or inconsistent, but
+// never silently wrong. Every chunk is a separate item, so a backend that
+// prompts on each read prompts once per chunk.
+//
+// Keys of the form "<key>#<n>" are reserved for chunks: Set returns an error
+// matching ErrInvalidKey for them, as they could be overwritten by the chunks
+// of <key> and would be hidden by Keys.
+func NewChunked(inner Keyring, chunkSize int) Keyring {
+	if chunkSize <= 0 {
+		chunkSize = MaxValueSize(inner)
+	}
+	return &chunkedKeyring{forwarding{inner}, chunkSize}
+}
+
+// manifest returns the manifest stored in item's data, or false if the item isn't chunked.
+func (k *chunkedKeyring) manifest(item Item) (chunkManifest, bool, error) {
+	if !bytes.HasPrefix(item.Data, []byte(chunkManifestMagic)) {
+		return chunkManifest{}, false, nil
+	}
+
+	var m chunkManifest
+	if err := json.Unmarshal(item.Data[len(chunkManifestMagic):], &m); err != nil {
+		return chunkManifest{}, false, err
+	}
+	return m, true, nil
+}
+
+func (k *chunkedKeyring) Get(key string) (Item, error) {
+	item, err := k.Keyring.Get(key)
+	if err != nil {
+		return Item{}, err
+	}
+
+	m, chunked, err := k.manifest(item)
+	if err != nil || !chunked {
+		return item, err
+	}
+
+	data := make([]byte, 0, m.Size)
+	for i := 0; i < m.Chunks; i++ {
+		chunk, err := k.Keyring.Get(chunkKey(key, i))
+		if errors.Is(err, ErrKeyNotFound) {
+			return Item{}, fmt.Errorf("%w: %s is missing", ErrInconsistentChunks, chunkKey(key, i))
+		} else if err != nil {
+			return Item{}, err
+		}
+		data = append(data, chunk.Data...)
+	}
+
+	if sum := sha256.Sum256(data); len(data) != m.Size || !bytes.Equal(sum[:], m.SHA256) {
+		return Item{}, ErrInconsistentChunks
+	}
+
+	item.Data = data
+	return item, nil
+}
+
+// GetWithCallback wipes the reassembled data, but not the chunks read from the inner Keyring.
+func (k *chunkedKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
+// Set writes the chunks before the manifest, so that readers never find a
+// manifest with missing chunks, and then removes any chunks left over from a
+// longer previous value.
+func (k *chunkedKeyring) Set(item Item) error {
+	if chunkKeyPattern.MatchString(item.Key) {
+		return fmt.Errorf("%w: %q is reserved for a chunk", ErrInvalidKey, item.Key)
+	}
+
+	previous := 0
+	if existing, err := k.Keyring.Get(item.Key); err == nil {
+		if m, chunked, err := k.manifest(existing); err == nil && chunked {
+			previous = m.Chunks
+		}
+	} else if !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	chunks := 0
+	if k.chunkSize > 0 && len(item.Data) > k.chunkSize {
+		for offset := 0; offset < len(item.Data); offset += k.chunkSize {
+			end := offset + k.chunkSize
+			if end > len(item.Data) {
+				end = len(item.Data)
+			}
+
+			chunk := item
+			chunk.Key = chunkKey(item.Key, chunks)
+			chunk.Data = item.Data[offset:end]
+			chunk.DataParts = nil
+			if err := k.Keyring.Set(chunk); err != nil {
+				return err
+			}
+			chunks++
+		}
+
+		sum := sha256.Sum256(item.Data)
+		encoded, err := json.Marshal(chunkManifest{Chunks: chunks, Size: len(item.Data), SHA256: sum[:]})
+		if err != nil {
+			return err
+		}
+		item.Data = append([]byte(chunkManifestMagic), encoded...)
+	}
+
+	if err := k.Keyring.Set(item); err != nil {
+		return err
+	}
+
+	return k.removeChunks(item.Key, chunks, previous)
+}
+
+// removeChunks removes the chunks of key numbered from up to, but not including, to.
+func (k *chunkedKeyring) removeChunks(key string, from, to int) error {
+	for i := from; i < to; i++ {
+		if err := k.Keyring.Remove(chunkKey(key, i)); err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove reads the manifest to find the chunks, so it may prompt for
+// credentials where Get does.
+func (k *chunkedKeyring) Remove(key string) error {
+	item, err := k.Keyring.Get(key)
+	if err != nil {
+		return err
+	}
+
+	m, _, err := k.manifest(item)
+	if err != nil {
+		return err
+	}
+
+	if err := k.Keyring.Remove(key); err != nil {
+		return err
+	}
+	return k.removeChunks(key, 0, m.Chunks)
+}
+
+// Keys hides the keys of chunks, i.e. "<key>#<n>" where <key> is also listed.
+func (k *chunkedKeyring) Keys() ([]string, error) {
+	keys, err := k.Keyring.Keys()
+	if keys == nil {
+		return nil, err
+	}
+	return hideChunks(keys), err
+}
+
+// hideChunks removes the keys of chunks of items in keys.
+func hideChunks(keys []string) []string {
+	listed := map[string]bool{}
+	for _, key := range keys {
+		listed[key] = true
+	}
+
+	logical := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !isChunk(key, listed) {
+			logical = append(logical, key)
+		}
+	}
+	return logical
+}
+
+// isChunk reports whether key is the key of a chunk of an item in listed.
+func isChunk(key string, listed map[string]bool) bool {
+	m := chunkKeyPattern.FindStringSubmatch(key)
+	return m != nil && listed[m[1]]
+}
+
+// KeysByCategory hides chunks, which have the category of their item.
+func (k *chunkedKeyring) KeysByCategory(category string) ([]string, error) {
+	keys, err := KeysByCategory(k.Keyring, category)
+	if err != nil {
+		return nil, err
+	}
+	return hideChunks(keys), nil
+}
+
+func (k *chunkedKeyring) KeysWithFlags() ([]KeyInfo, error) {
+	infos, err := KeysWithFlags(k.Keyring)
+	if infos == nil {
+		return nil, err
+	}
+
+	listed := map[string]bool{}
+	for _, info := range infos {
+		listed[info.Key] = true
+	}
+	logical := make([]KeyInfo, 0, len(infos))
+	for _, info := range infos {
+		if !isChunk(info.Key, listed) {
+			logical = append(logical, info)
+		}
+	}
+	return logical, err
+}
+
+func (k *chunkedKeyring) Entries() ([]Metadata, error) {
+	entries, err := Entries(k.Keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := map[string]bool{}
+	for _, md := range entries {
+		if md.Item != nil {
+			listed[md.Key] = true
+		}
+	}
+	logical := make([]Metadata, 0, len(entries))
+	for _, md := range entries {
+		if md.Item == nil || !isChunk(md.Key, listed) {
+			logical = append(logical, md)
+		}
+	}
+	return logical, nil
+}
+
+func (k *chunkedKeyring) RemoveAll() error {
+	return RemoveAll(k.Keyring)
+}
+
+// RemoveMatching passes pred the keys of items, not chunks, and removes the
+// chunks of the items it removes.
+func (k *chunkedKeyring) RemoveMatching(pred func(key string) bool) (int, error) {
+	return removeMatching(k, pred)
+}
+
+// Update reassembles the item and writes it back in chunks like Set.
+func (k *chunkedKeyring) Update(key string, mutate func(*Item) error) error {
+	item, err := k.Get(key)
+	if err != nil {
+		return err
+	}
+	if err = applyUpdate(&item, mutate); err != nil {
+		return err
+	}
+	return k.Set(item)
+}
+
+// Touch bumps the modification time of the item's manifest.
+func (k *chunkedKeyring) Touch(key string) error {
+	return Touch(k.Keyring, key)
+}
+
+// MaxValueSize is 0, as values of any size are split into chunks.
+func (k *chunkedKeyring) MaxValueSize() int {
+	return 0
+}
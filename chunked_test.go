@@ -0,0 +1,158 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestChunkedKeyring(t *testing.T) {
+	inner := NewArrayKeyring(nil)
+	k := NewChunked(inner, 4)
+
+	data := []byte("llamas are great")
+	if err := k.Set(Item{Key: "llamas", Data: data, Label: "Llamas"}); err != nil {
+		t.Fatal(err)
+	}
+
+	innerKeys, _ := inner.Keys()
+	if len(innerKeys) != 5 {
+		t.Fatalf("Expected a manifest and 4 chunks, got %v", innerKeys)
+	}
+	for _, key := range innerKeys {
+		if item, _ := inner.Get(key); key != "llamas" && len(item.Data) > 4 {
+			t.Fatalf("Expected chunks of at most 4 bytes, got %q", item.Data)
+		}
+	}
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(item.Data, data) || item.Label != "Llamas" {
+		t.Fatalf("Unexpected item %#v", item)
+	}
+
+	if err := k.Set(Item{Key: "alpacas", Data: []byte("ok")}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "alpacas" || keys[1] != "llamas" {
+		t.Fatalf("Expected [alpacas llamas], got %v", keys)
+	}
+
+	// a shorter value removes the chunks it no longer needs
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas!")}); err != nil {
+		t.Fatal(err)
+	}
+	if innerKeys, _ := inner.Keys(); len(innerKeys) != 4 {
+		t.Fatalf("Expected alpacas, a manifest and 2 chunks, got %v", innerKeys)
+	}
+
+	if err := k.Remove("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	if innerKeys, _ := inner.Keys(); len(innerKeys) != 1 {
+		t.Fatalf("Expected only alpacas to remain, got %v", innerKeys)
+	}
+}
+
+func TestChunkedKeyringReservesChunkKeys(t *testing.T) {
+	inner := NewArrayKeyring(nil)
+	k := NewChunked(inner, 4)
+
+	if err := k.Set(Item{Key: "llamas#0", Data: []byte("ok")}); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Expected ErrInvalidKey, got %v", err)
+	}
+	if err := k.Set(Item{Key: "llamas#one", Data: []byte("ok")}); err != nil {
+		t.Fatalf("Expected a key that isn't a chunk's to be stored, got %v", err)
+	}
+}
+
+func TestChunkedKeyringMissingChunk(t *testing.T) {
+	inner := NewArrayKeyring(nil)
+	k := NewChunked(inner, 4)
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Remove("llamas#2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := k.Get("llamas"); !errors.Is(err, ErrInconsistentChunks) {
+		t.Fatalf("Expected ErrInconsistentChunks, got %v", err)
+	}
+}
+
+// sizeLimitedKeyring has a small value size limit, like wincred's.
+type sizeLimitedKeyring struct {
+	*ArrayKeyring
+}
+
+func (k sizeLimitedKeyring) MaxValueSize() int {
+	return 4
+}
+
+func TestChunkedKeyringHidesChunksFromHelpers(t *testing.T) {
+	inner := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	k := NewChunked(inner, 4)
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great"), Category: "camelids"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Set(Item{Key: "alpacas", Data: []byte("ok"), Category: "camelids"}); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := KeysByCategory(k, "camelids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "alpacas" || keys[1] != "llamas" {
+		t.Fatalf("Expected [alpacas llamas], got %v", keys)
+	}
+
+	entries, err := Entries(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	if MaxValueSize(NewChunked(sizeLimitedKeyring{NewArrayKeyring(nil)}, 0)) != 0 {
+		t.Fatal("Expected a chunked keyring to have no value size limit")
+	}
+
+	err = Update(k, "llamas", func(item *Item) error {
+		item.Data = []byte("llamas are still great")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item, err := k.Get("llamas"); err != nil || string(item.Data) != "llamas are still great" {
+		t.Fatalf("Unexpected item after Update: %q, %v", item.Data, err)
+	}
+
+	var seen []string
+	removed, err := RemoveMatching(k, func(key string) bool {
+		seen = append(seen, key)
+		return key == "llamas"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 || len(seen) != 2 {
+		t.Fatalf("Expected the predicate to see 2 items and remove 1, got %v and %d removed", seen, removed)
+	}
+	if innerKeys, _ := inner.Keys(); len(innerKeys) != 1 || innerKeys[0] != "alpacas" {
+		t.Fatalf("Expected the chunks of llamas to be removed, got %v", innerKeys)
+	}
+}
@@ -0,0 +1,291 @@
+package keyring
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// The cli backend stores items by running the platform's credential tool:
+// `security` on macOS and `secret-tool` on Linux. It needs neither cgo nor a
+// D-Bus library, for environments where the native backends can't be built
+// or loaded, but every operation starts a process, which costs milliseconds
+// rather than microseconds. It isn't in backendOrder, so it is only used when
+// listed in Config.AllowedBackends or set as Config.RequireBackend.
+//
+// Windows isn't supported, as cmdkey can't read stored passwords back.
+func init() {
+	supportedBackends[CLIBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
+		if cfg.ServiceName == "" {
+			return nil, ErrServiceNameRequired
+		}
+
+		var tool cliTool
+		switch runtime.GOOS {
+		case "darwin":
+			tool = securityCLI{}
+		case "linux", "freebsd", "openbsd", "netbsd":
+			tool = secretToolCLI{}
+		default:
+			debugf("No credential tool for %s", runtime.GOOS)
+			return nil, ErrNoAvailImpl
+		}
+
+		if _, err := exec.LookPath(tool.name()); err != nil {
+			debugf("Credential tool %s not available: %v", tool.name(), err)
+			return nil, ErrNoAvailImpl
+		}
+
+		return &cliKeyring{
+			tool:           tool,
+			service:        cfg.ServiceName,
+			run:            runCLI,
+			allowEmptyKeys: cfg.AllowEmptyKeys,
+		}, nil
+	})
+}
+
+// runCLI runs name with args, writing stdin to it, and returns its output.
+func runCLI(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	return cmd.Output()
+}
+
+// cliTool builds the command lines for a credential tool and parses their output.
+type cliTool interface {
+	name() string
+	get(run cliRunner, service, key string) ([]byte, error)
+	set(run cliRunner, service string, item Item, secret []byte) error
+	remove(run cliRunner, service, key string) error
+	keys(run cliRunner, service string) ([]string, error)
+}
+
+type cliRunner func(stdin []byte, name string, args ...string) ([]byte, error)
+
+type cliKeyring struct {
+	tool           cliTool
+	service        string
+	run            cliRunner
+	allowEmptyKeys bool
+}
+
+// The secret stored for an item is its JSON encoding, as in the pass and
+// secret-service backends, so that labels and descriptions survive.
+func (k *cliKeyring) Get(key string) (Item, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
+	secret, err := k.tool.get(k.run, k.service, key)
+	if err != nil {
+		return Item{}, err
+	}
+
+	var item Item
+	err = json.Unmarshal(secret, &item)
+	return item, err
+}
+
+func (k *cliKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
+// GetMetadata isn't supported, as the tools print the secret with the attributes.
+func (k *cliKeyring) GetMetadata(_ string) (Metadata, error) {
+	return Metadata{}, ErrMetadataNotSupported
+}
+
+func (k *cliKeyring) Set(item Item) error {
+	if err := checkKey(item.Key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
+	secret, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return k.tool.set(k.run, k.service, item, secret)
+}
+
+func (k *cliKeyring) Remove(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+	return k.tool.remove(k.run, k.service, key)
+}
+
+func (k *cliKeyring) Keys() ([]string, error) {
+	return k.tool.keys(k.run, k.service)
+}
+
+func (k *cliKeyring) BackendType() BackendType {
+	return CLIBackend
+}
+
+// exitCode returns the exit status of a command that ran, such as an
+// *exec.ExitError, or -1.
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// secretToolCLI drives libsecret's secret-tool, storing items in the default
+// collection with the same service and account attributes as the
+// secret-service backend.
+type secretToolCLI struct{}
+
+func (secretToolCLI) name() string {
+	return "secret-tool"
+}
+
+func (t secretToolCLI) get(run cliRunner, service, key string) ([]byte, error) {
+	out, err := run(nil, t.name(), "lookup", "service", service, "account", key)
+	if exitCode(err) == 1 && len(out) == 0 {
+		return nil, ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// set passes the secret on stdin, so it doesn't show up in the process list.
+func (t secretToolCLI) set(run cliRunner, service string, item Item, secret []byte) error {
+	label := item.Label
+	if label == "" {
+		label = item.Key
+	}
+	_, err := run(secret, t.name(), "store", "--label="+label, "service", service, "account", item.Key)
+	return err
+}
+
+func (t secretToolCLI) remove(run cliRunner, service, key string) error {
+	if _, err := t.get(run, service, key); err != nil {
+		return err
+	}
+	_, err := run(nil, t.name(), "clear", "service", service, "account", key)
+	return err
+}
+
+// keys parses the "attribute.account = <key>" lines that search prints for each item.
+func (t secretToolCLI) keys(run cliRunner, service string) ([]string, error) {
+	out, err := run(nil, t.name(), "search", "--all", "service", service)
+	if exitCode(err) == 1 && len(out) == 0 {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "attribute.account = ") {
+			keys = append(keys, strings.TrimPrefix(line, "attribute.account = "))
+		}
+	}
+	return keys, scanner.Err()
+}
+
+// securityCLI drives macOS's security tool on the default keychain. security
+// only takes a new password as an argument, where other local users can see it
+// in the process list while the command runs; it is base64 encoded so that
+// find-generic-password prints it back verbatim.
+type securityCLI struct{}
+
+// securityItemNotFoundStatus is security's exit status for a missing item, errSecItemNotFound truncated to a byte.
+const securityItemNotFoundStatus = 44
+
+func (securityCLI) name() string {
+	return "security"
+}
+
+func (t securityCLI) get(run cliRunner, service, key string) ([]byte, error) {
+	out, err := run(nil, t.name(), "find-generic-password", "-s", service, "-a", key, "-w")
+	if exitCode(err) == securityItemNotFoundStatus {
+		return nil, ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+}
+
+func (t securityCLI) set(run cliRunner, service string, item Item, secret []byte) error {
+	args := []string{"add-generic-password", "-U", "-s", service, "-a", item.Key}
+	if item.Label != "" {
+		args = append(args, "-l", item.Label)
+	}
+	if item.Description != "" {
+		args = append(args, "-D", item.Description)
+	}
+	args = append(args, "-w", base64.StdEncoding.EncodeToString(secret))
+
+	_, err := run(nil, t.name(), args...)
+	return err
+}
+
+func (t securityCLI) remove(run cliRunner, service, key string) error {
+	_, err := run(nil, t.name(), "delete-generic-password", "-s", service, "-a", key)
+	if exitCode(err) == securityItemNotFoundStatus {
+		return ErrKeyNotFound
+	}
+	return err
+}
+
+// keys parses the attributes that dump-keychain prints for every item,
+// matching the "svce" and "acct" attributes of generic passwords.
+func (t securityCLI) keys(run cliRunner, service string) ([]string, error) {
+	out, err := run(nil, t.name(), "dump-keychain")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	var class, svce, acct string
+	flush := func() {
+		if class == "genp" && svce == service && acct != "" {
+			keys = append(keys, acct)
+		}
+		class, svce, acct = "", "", ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "keychain: "):
+			flush()
+		case strings.HasPrefix(line, "class: "):
+			class = strings.Trim(strings.TrimPrefix(line, "class: "), `"`)
+		case strings.HasPrefix(line, `"svce"<blob>=`):
+			svce = securityAttributeValue(line)
+		case strings.HasPrefix(line, `"acct"<blob>=`):
+			acct = securityAttributeValue(line)
+		}
+	}
+	flush()
+
+	sort.Strings(keys)
+	return keys, scanner.Err()
+}
+
+// securityAttributeValue returns the quoted value of a dump-keychain
+// attribute line such as `"acct"<blob>="llamas"`, or "" for <NULL> and
+// values that are only printed in hex.
+func securityAttributeValue(line string) string {
+	value := line[strings.Index(line, "=")+1:]
+	if !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) || len(value) < 2 {
+		return ""
+	}
+	return value[1 : len(value)-1]
+}
@@ -0,0 +1,154 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeExitError int
+
+func (e fakeExitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e fakeExitError) ExitCode() int {
+	return int(e)
+}
+
+// fakeSecretTool answers secret-tool command lines from an in-memory map of
+// account to secret.
+func fakeSecretTool(secrets map[string][]byte) cliRunner {
+	return func(stdin []byte, name string, args ...string) ([]byte, error) {
+		switch args[0] {
+		case "store":
+			secrets[args[len(args)-1]] = stdin
+			return nil, nil
+		case "lookup":
+			if secret, ok := secrets[args[len(args)-1]]; ok {
+				return secret, nil
+			}
+			return nil, fakeExitError(1)
+		case "clear":
+			delete(secrets, args[len(args)-1])
+			return nil, nil
+		case "search":
+			if len(secrets) == 0 {
+				return nil, fakeExitError(1)
+			}
+			var out strings.Builder
+			for account := range secrets {
+				fmt.Fprintf(&out, "[/1]\nlabel = %s\nsecret = x\nattribute.service = llamas\nattribute.account = %s\n", account, account)
+			}
+			return []byte(out.String()), nil
+		}
+		return nil, fmt.Errorf("unexpected command %s %v", name, args)
+	}
+}
+
+func TestCLIKeyringSecretTool(t *testing.T) {
+	k := &cliKeyring{tool: secretToolCLI{}, service: "llamas", run: fakeSecretTool(map[string][]byte{})}
+
+	if _, err := k.Get("llamas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if keys, err := k.Keys(); err != nil || len(keys) != 0 {
+		t.Fatalf("Expected no keys, got %v, %v", keys, err)
+	}
+
+	item := Item{Key: "llamas", Data: []byte("llamas are great"), Label: "Llamas"}
+	if err := k.Set(item); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Data) != "llamas are great" || got.Label != "Llamas" {
+		t.Fatalf("Unexpected item %#v", got)
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "llamas" {
+		t.Fatalf("Expected [llamas], got %v", keys)
+	}
+
+	if err := k.Remove("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Remove("llamas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if err := k.Set(Item{}); err != ErrEmptyKey {
+		t.Fatalf("Expected ErrEmptyKey, got %v", err)
+	}
+}
+
+func TestCLIKeyringSecurity(t *testing.T) {
+	var added []string
+	run := func(stdin []byte, name string, args ...string) ([]byte, error) {
+		switch args[0] {
+		case "add-generic-password":
+			added = args
+			return nil, nil
+		case "find-generic-password":
+			if added == nil {
+				return nil, fakeExitError(securityItemNotFoundStatus)
+			}
+			return []byte(added[len(added)-1] + "\n"), nil
+		case "dump-keychain":
+			return []byte(`keychain: "/Users/llama/Library/Keychains/login.keychain-db"
+version: 512
+class: "genp"
+attributes:
+    "acct"<blob>="llamas"
+    "svce"<blob>="llamas"
+keychain: "/Users/llama/Library/Keychains/login.keychain-db"
+version: 512
+class: "genp"
+attributes:
+    "acct"<blob>="alpacas"
+    "svce"<blob>="alpacas"
+keychain: "/Users/llama/Library/Keychains/login.keychain-db"
+version: 512
+class: "inet"
+attributes:
+    "acct"<blob>="vicunas"
+    "svce"<blob>=<NULL>
+`), nil
+		}
+		return nil, fmt.Errorf("unexpected command %s %v", name, args)
+	}
+	k := &cliKeyring{tool: securityCLI{}, service: "llamas", run: run}
+
+	if _, err := k.Get("llamas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := base64.StdEncoding.DecodeString(added[len(added)-1]); err != nil {
+		t.Fatalf("Expected a base64 password argument, got %v", err)
+	}
+
+	got, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Data) != "llamas are great" {
+		t.Fatalf("Unexpected item %#v", got)
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "llamas" {
+		t.Fatalf("Expected [llamas], got %v", keys)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -64,7 +65,9 @@ func main() {
 				*serviceName, allowedBackends[0])
 		}
 		keys, err := ring.Keys()
-		if err != nil {
+		if errors.Is(err, keyring.ErrPartialResults) {
+			log.Printf("Warning: %v", err)
+		} else if err != nil {
 			log.Fatalf("Failed to list keys: %#v", err)
 		}
 		for _, key := range keys {
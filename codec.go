@@ -0,0 +1,48 @@
+package keyring
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FileCodec serializes Items for the file backend. It is applied inside the
+// encryption envelope, and its name is recorded in each file's header so that
+// the backend can tell which codec a file was written with.
+type FileCodec interface {
+	// Returns an identifier for the format, unique among codecs
+	Name() string
+	// Serializes an Item
+	Marshal(item Item) ([]byte, error)
+	// Deserializes data written by Marshal into item
+	Unmarshal(data []byte, item *Item) error
+}
+
+// JSONFileCodec is the default FileCodec, storing Items as JSON.
+var JSONFileCodec FileCodec = jsonFileCodec{}
+
+type jsonFileCodec struct{}
+
+func (jsonFileCodec) Name() string {
+	return "json"
+}
+
+func (jsonFileCodec) Marshal(item Item) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (jsonFileCodec) Unmarshal(data []byte, item *Item) error {
+	return json.Unmarshal(data, item)
+}
+
+// fileCodecFor returns the codec named in a file header: the configured codec
+// if the names match, or JSONFileCodec for files without a name, which were
+// all written as JSON.
+func fileCodecFor(name string, configured FileCodec) (FileCodec, error) {
+	switch name {
+	case configured.Name():
+		return configured, nil
+	case "", JSONFileCodec.Name():
+		return JSONFileCodec, nil
+	}
+	return nil, fmt.Errorf("file was written with unknown codec %q", name)
+}
@@ -0,0 +1,57 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+type gobFileCodec struct{}
+
+func (gobFileCodec) Name() string {
+	return "gob"
+}
+
+func (gobFileCodec) Marshal(item Item) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(item)
+	return buf.Bytes(), err
+}
+
+func (gobFileCodec) Unmarshal(data []byte, item *Item) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(item)
+}
+
+func TestFileKeyringCodec(t *testing.T) {
+	dir := t.TempDir()
+	jsonKeyring := &fileKeyring{
+		dir:          dir,
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	gobKeyring := &fileKeyring{
+		dir:          dir,
+		passwordFunc: FixedStringPrompt("no more secrets"),
+		codec:        gobFileCodec{},
+	}
+
+	if err := jsonKeyring.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gobKeyring.Set(Item{Key: "alpacas", Data: []byte("alpacas are better")}); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, data := range map[string]string{"llamas": "llamas are great", "alpacas": "alpacas are better"} {
+		item, err := gobKeyring.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(item.Data) != data {
+			t.Fatalf("Value stored was not the value retrieved: %q", item.Data)
+		}
+	}
+
+	if _, err := jsonKeyring.Get("alpacas"); err == nil {
+		t.Fatal("Expected an error reading a file written with an unknown codec")
+	}
+}
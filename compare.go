@@ -0,0 +1,45 @@
+package keyring
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// VerifyOption changes the behaviour of a single Verify call.
+type VerifyOption func(*verifyOptions)
+
+type verifyOptions struct {
+	reportMissing bool
+}
+
+// ReportMissing makes Verify return ErrKeyNotFound for a missing key, rather
+// than false as for a mismatch.
+func ReportMissing() VerifyOption {
+	return func(o *verifyOptions) {
+		o.reportMissing = true
+	}
+}
+
+// Verify reports whether candidate matches the data of the item with the
+// given key, e.g. to check an API key presented by a client against the
+// stored one. The data is compared in constant time and wiped afterwards, see
+// GetWithCallback, and never returned to the caller. A missing key doesn't
+// match, unless ReportMissing is given.
+func Verify(kr Keyring, key string, candidate []byte, opts ...VerifyOption) (bool, error) {
+	var o verifyOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var match bool
+	err := GetWithCallback(kr, key, func(data []byte) error {
+		match = subtle.ConstantTimeCompare(data, candidate) == 1
+		return nil
+	})
+	if errors.Is(err, ErrKeyNotFound) && !o.reportMissing {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return match, nil
+}
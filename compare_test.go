@@ -0,0 +1,48 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	kr := NewArrayKeyring([]Item{{Key: "api-key", Data: []byte("llamas are great")}})
+
+	for candidate, want := range map[string]bool{
+		"llamas are great":  true,
+		"llamas are grea":   false,
+		"llamas are great!": false,
+		"alpacas are great": false,
+		"":                  false,
+	} {
+		match, err := Verify(kr, "api-key", []byte(candidate))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != want {
+			t.Fatalf("Verify(%q) = %v, want %v", candidate, match, want)
+		}
+	}
+
+	// the stored item is left alone
+	item, err := kr.Get("api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Stored data changed to %q", item.Data)
+	}
+}
+
+func TestVerifyMissingKey(t *testing.T) {
+	kr := NewArrayKeyring(nil)
+
+	match, err := Verify(kr, "api-key", []byte("llamas are great"))
+	if err != nil || match {
+		t.Fatalf("Expected no match and no error, got %v, %v", match, err)
+	}
+
+	if _, err := Verify(kr, "api-key", []byte("llamas are great"), ReportMissing()); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
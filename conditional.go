@@ -0,0 +1,36 @@
+package keyring
+
+import (
+	"errors"
+	"time"
+)
+
+// GetIfModifiedSince returns the item and true if it was modified after t,
+// or a zero Item and false if it wasn't. The modification time comes from
+// GetMetadata, which doesn't read the data or prompt, so an unchanged item
+// costs no Get. When the backend can't report modification times without
+// credentials, or reports none, the item is always fetched and reported as
+// modified.
+//
+// Backends record modification times with different precision, the keychain
+// to the second, so t is best taken from the ModificationTime of an earlier
+// GetMetadata rather than from the clock.
+func GetIfModifiedSince(kr Keyring, key string, t time.Time) (Item, bool, error) {
+	md, err := kr.GetMetadata(key)
+	switch {
+	case errors.Is(err, ErrMetadataNotSupported), errors.Is(err, ErrMetadataNeedsCredentials):
+		debugf("No metadata for %q, fetching it: %v", key, err)
+	case err != nil:
+		return Item{}, false, err
+	case md.ModificationTime.IsZero():
+		debugf("No modification time for %q, fetching it", key)
+	case !md.ModificationTime.After(t):
+		return Item{}, false, nil
+	}
+
+	item, err := kr.Get(key)
+	if err != nil {
+		return Item{}, false, err
+	}
+	return item, true, nil
+}
@@ -0,0 +1,44 @@
+package keyring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetIfModifiedSince(t *testing.T) {
+	k := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	modified := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(k.dir, "llamas"), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, changed, err := GetIfModifiedSince(k, "llamas", modified); err != nil || changed {
+		t.Fatalf("Expected an unchanged item, got %v, %v", changed, err)
+	}
+
+	item, changed, err := GetIfModifiedSince(k, "llamas", modified.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || string(item.Data) != "llamas are great" {
+		t.Fatalf("Expected the modified item, got %v, %q", changed, item.Data)
+	}
+}
+
+func TestGetIfModifiedSinceWithoutMetadata(t *testing.T) {
+	k := NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}})
+
+	item, changed, err := GetIfModifiedSince(k, "llamas", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || string(item.Data) != "llamas are great" {
+		t.Fatalf("Expected the item to be fetched, got %v, %q", changed, item.Data)
+	}
+}
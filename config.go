@@ -1,16 +1,67 @@
 package keyring
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Config contains configuration for keyring.
 type Config struct {
 	// AllowedBackends is a whitelist of backend providers that can be used. Nil means all available.
 	AllowedBackends []BackendType
 
-	// ServiceName is a generic service name that is used by backends that support the concept
+	// OnFallback is an optional function called by Open each time a backend fails to open and
+	// Open moves on to the next one, which is InvalidBackend if there are none left
+	OnFallback func(from, to BackendType, reason error)
+
+	// RequireBackend is a backend that must be used. If set, Open returns ErrBackendNotAvailable
+	// when it can't be opened rather than falling back, and AllowedBackends is ignored
+	RequireBackend BackendType
+
+	// ServiceName is a generic service name that is used by backends that support the concept.
+	// The keychain backend requires it and returns ErrServiceNameRequired without it. Otherwise
+	// wincred defaults to "default", secret-service to "secret-service" and kwallet to "kdewallet",
+	// keyctl uses the scope's keyring directly, and pass and file don't use it
 	ServiceName string
 
+	// VerifyOnWrite is whether Set reads the item back and returns ErrWriteVerificationFailed
+	// if the stored data doesn't match. This costs an extra read, which may prompt for
+	// credentials on backends that require them to read item data. That includes the keychain:
+	// it has no metadata-only check of the data's length, as the pinned go-keychain only returns
+	// the length with the data itself
+	VerifyOnWrite bool
+
+	// CaseInsensitiveKeys is whether keys are lower cased before they reach the backend, so that keys
+	// differing only in case refer to the same item on every backend, as they always do on wincred.
+	// Items stored under keys with upper case letters before this was set can't be read with it set.
+	// Only the Keyring methods are affected; helpers such as Touch and Update pass keys through as is
+	CaseInsensitiveKeys bool
+
+	// AllowEmptyKeys is whether Get, GetMetadata, Set and Remove accept an empty key. By default
+	// they return ErrEmptyKey, as an empty key is usually an uninitialized variable, and the
+	// keychain backend treats an empty account as matching any item. The file backend stores the
+	// empty key in a file named "%". ArrayKeyring isn't opened with a Config and always accepts them
+	AllowEmptyKeys bool
+
+	// ConstantTimeLookups is whether the file backend's Get takes about as long for a missing key as for
+	// an existing one, by decrypting a decoy item, so that timing doesn't reveal which keys exist. This is
+	// a mitigation, not a guarantee, and costs a decryption per miss. The OS backends' timing can't be
+	// controlled, so it doesn't apply to them. See ArrayOptions.ConstantTimeLookups for ArrayKeyring
+	ConstantTimeLookups bool
+
+	// Observer is an optional hook that is told the duration and error of every operation
+	Observer Observer
+
 	// MacOSKeychainNameKeychainName is the name of the macOS keychain that is used
 	KeychainName string
 
+	// KeychainDomain is the keychain domain the keychain backend uses (either "user" or "system").
+	// Defaults to "user", the login keychain or KeychainName. "system" uses the system keychain shared
+	// by all users, for launch daemons storing machine-wide secrets; only root can write to it, so Open
+	// returns ErrKeychainDomainUnavailable for other users. "dynamic" is recognized but always returns
+	// ErrKeychainDomainUnavailable, as its keychains are provided by smart cards and can't hold items
+	KeychainDomain string
+
 	// KeychainTrustApplication is whether the calling application should be trusted by default by items
 	KeychainTrustApplication bool
 
@@ -20,16 +71,66 @@ type Config struct {
 	// KeychainAccessibleWhenUnlocked is whether the item is accessible when the device is locked
 	KeychainAccessibleWhenUnlocked bool
 
+	// KeychainReapplyAccessOnUpdate is whether Set replaces an existing item so that the current
+	// access settings take effect, instead of updating it in place and keeping its original access
+	KeychainReapplyAccessOnUpdate bool
+
+	// KeychainQuietUpserts is whether Set skips the debug message logged when it updates an existing item,
+	// which is the normal path for callers that refresh the same key frequently
+	KeychainQuietUpserts bool
+
+	// KeychainConcurrentPrompts is whether reads of item data may run concurrently. By default they
+	// run one at a time, so that concurrent Gets show one access prompt after another instead of
+	// stacking them
+	KeychainConcurrentPrompts bool
+
+	// RequireUniqueLabels is whether the keychain backend's Set returns ErrDuplicateLabel when another item
+	// of the service already has the item's label, for apps that look items up by label. Empty labels
+	// aren't checked. It costs an attribute query per Set, which doesn't prompt
+	RequireUniqueLabels bool
+
+	// TraceKeychainQueries is whether the keychain backend logs every attribute of each query and item
+	// it passes to the Security framework, such as the match limit and synchronizable flag, to debug
+	// queries that unexpectedly find nothing. Item data is only logged by length. Like other debug
+	// messages, nothing is logged unless Debug is set
+	TraceKeychainQueries bool
+
 	// KeychainPasswordFunc is an optional function used to prompt the user for a password
 	KeychainPasswordFunc PromptFunc
 
 	// FilePasswordFunc is a required function used to prompt the user for a password
 	FilePasswordFunc PromptFunc
 
+	// FilePasswordMaxAttempts is how many times the file backend prompts with FilePasswordFunc when
+	// the passphrase is wrong, before returning ErrMaxPasswordAttempts. A corrupt file fails on the
+	// first attempt. Zero or 1 prompts once and returns the decryption error, as it always did
+	FilePasswordMaxAttempts int
+
+	// FileCodec is an optional serialization for items in the file backend, defaults to JSONFileCodec.
+	// Files written with JSONFileCodec can always be read, whichever codec is configured
+	FileCodec FileCodec
+
 	// FileDir is the directory that keyring files are stored in, ~/ is resolved to the users' home dir
 	FileDir string
 
-	// KeyCtlScope is the scope of the kernel keyring (either "user", "session", "process" or "thread")
+	// FileKeepVersions is how many previous values of each item the file backend keeps when it is Set,
+	// readable with GetVersion and Versions. Older ones are deleted. Zero keeps none
+	FileKeepVersions int
+
+	// FileNoSyncOnWrite is whether the file backend skips syncing each item file and the directory to disk
+	// when it writes them. By default Set only returns once the item would survive a crash or power loss,
+	// which costs a disk flush per write. Callers writing many items can set this and call Sync once
+	FileNoSyncOnWrite bool
+
+	// FileStrictPermissions is whether opening the file backend fails with ErrInsecurePermissions,
+	// rather than logging a debug warning, when FileDir or its files are accessible by other users
+	FileStrictPermissions bool
+
+	// KeyCtlScope is the scope of the kernel keyring (either "user", "usersession", "session", "process"
+	// or "thread"), see keyrings(7). Keys in the "user" keyring are kept while the user has any session,
+	// "usersession" and "session" keys go with the login session, and "process" and "thread" keys with the
+	// process or thread. There is no default, Open fails without a scope. "user_session" is accepted as
+	// an alias of "usersession"
 	KeyCtlScope string
 
 	// KeyCtlPerm is the permission mask to use for new keys
@@ -53,6 +154,87 @@ type Config struct {
 	// PassPrefix is a string prefix to prepend to the item path stored in pass
 	PassPrefix string
 
+	// PassInitIfMissing is whether Open runs `pass init` with PassGpgRecipients when the password store
+	// hasn't been initialized. An initialized store is never reinitialized. Open fails if gpg has no
+	// public key for one of the recipients
+	PassInitIfMissing bool
+
+	// PassGpgRecipients are the gpg key ids or email addresses a store created by PassInitIfMissing
+	// encrypts items for
+	PassGpgRecipients []string
+
 	// WinCredPrefix is a string prefix to prepend to the key name
 	WinCredPrefix string
+
+	// WinCredPersist is the persistence of new credentials (either "local_machine", "session" or "enterprise").
+	// Defaults to "local_machine". "enterprise" credentials roam with the user profile, which requires
+	// domain membership
+	WinCredPersist string
+}
+
+// Redacted returns a copy of c without its password prompts, which may hold
+// or fetch passphrases, so that it can be logged or kept for diagnostics.
+func (c Config) Redacted() Config {
+	c.KeychainPasswordFunc = nil
+	c.FilePasswordFunc = nil
+	return c
+}
+
+// String describes the non-secret fields of c that are set, e.g. for logging
+// the effective configuration before calling Open. Password prompts and
+// callbacks are only reported as being set.
+func (c Config) String() string {
+	fields := []string{}
+	add := func(name string, value interface{}, set bool) {
+		if set {
+			fields = append(fields, fmt.Sprintf("%s: %v", name, value))
+		}
+	}
+	addFunc := func(name string, set bool) {
+		add(name, "set", set)
+	}
+
+	add("AllowedBackends", c.AllowedBackends, c.AllowedBackends != nil)
+	addFunc("OnFallback", c.OnFallback != nil)
+	add("RequireBackend", c.RequireBackend, c.RequireBackend != InvalidBackend)
+	add("ServiceName", fmt.Sprintf("%q", c.ServiceName), c.ServiceName != "")
+	add("VerifyOnWrite", c.VerifyOnWrite, c.VerifyOnWrite)
+	add("CaseInsensitiveKeys", c.CaseInsensitiveKeys, c.CaseInsensitiveKeys)
+	add("AllowEmptyKeys", c.AllowEmptyKeys, c.AllowEmptyKeys)
+	add("ConstantTimeLookups", c.ConstantTimeLookups, c.ConstantTimeLookups)
+	addFunc("Observer", c.Observer != nil)
+	add("KeychainName", fmt.Sprintf("%q", c.KeychainName), c.KeychainName != "")
+	add("KeychainDomain", fmt.Sprintf("%q", c.KeychainDomain), c.KeychainDomain != "")
+	add("KeychainTrustApplication", c.KeychainTrustApplication, c.KeychainTrustApplication)
+	add("KeychainSynchronizable", c.KeychainSynchronizable, c.KeychainSynchronizable)
+	add("KeychainAccessibleWhenUnlocked", c.KeychainAccessibleWhenUnlocked, c.KeychainAccessibleWhenUnlocked)
+	add("KeychainReapplyAccessOnUpdate", c.KeychainReapplyAccessOnUpdate, c.KeychainReapplyAccessOnUpdate)
+	add("KeychainQuietUpserts", c.KeychainQuietUpserts, c.KeychainQuietUpserts)
+	add("KeychainConcurrentPrompts", c.KeychainConcurrentPrompts, c.KeychainConcurrentPrompts)
+	add("RequireUniqueLabels", c.RequireUniqueLabels, c.RequireUniqueLabels)
+	add("TraceKeychainQueries", c.TraceKeychainQueries, c.TraceKeychainQueries)
+	addFunc("KeychainPasswordFunc", c.KeychainPasswordFunc != nil)
+	addFunc("FilePasswordFunc", c.FilePasswordFunc != nil)
+	add("FilePasswordMaxAttempts", c.FilePasswordMaxAttempts, c.FilePasswordMaxAttempts != 0)
+	if c.FileCodec != nil {
+		add("FileCodec", fmt.Sprintf("%q", c.FileCodec.Name()), true)
+	}
+	add("FileDir", fmt.Sprintf("%q", c.FileDir), c.FileDir != "")
+	add("FileKeepVersions", c.FileKeepVersions, c.FileKeepVersions != 0)
+	add("FileNoSyncOnWrite", c.FileNoSyncOnWrite, c.FileNoSyncOnWrite)
+	add("FileStrictPermissions", c.FileStrictPermissions, c.FileStrictPermissions)
+	add("KeyCtlScope", fmt.Sprintf("%q", c.KeyCtlScope), c.KeyCtlScope != "")
+	add("KeyCtlPerm", fmt.Sprintf("0x%x", c.KeyCtlPerm), c.KeyCtlPerm != 0)
+	add("KWalletAppID", fmt.Sprintf("%q", c.KWalletAppID), c.KWalletAppID != "")
+	add("KWalletFolder", fmt.Sprintf("%q", c.KWalletFolder), c.KWalletFolder != "")
+	add("LibSecretCollectionName", fmt.Sprintf("%q", c.LibSecretCollectionName), c.LibSecretCollectionName != "")
+	add("PassDir", fmt.Sprintf("%q", c.PassDir), c.PassDir != "")
+	add("PassCmd", fmt.Sprintf("%q", c.PassCmd), c.PassCmd != "")
+	add("PassPrefix", fmt.Sprintf("%q", c.PassPrefix), c.PassPrefix != "")
+	add("PassInitIfMissing", c.PassInitIfMissing, c.PassInitIfMissing)
+	add("PassGpgRecipients", c.PassGpgRecipients, c.PassGpgRecipients != nil)
+	add("WinCredPrefix", fmt.Sprintf("%q", c.WinCredPrefix), c.WinCredPrefix != "")
+	add("WinCredPersist", fmt.Sprintf("%q", c.WinCredPersist), c.WinCredPersist != "")
+
+	return "keyring.Config{" + strings.Join(fields, ", ") + "}"
 }
@@ -0,0 +1,32 @@
+package keyring
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := Config{
+		ServiceName:      "llamas",
+		FileDir:          "~/.llamas",
+		FilePasswordFunc: FixedStringPrompt("no more secrets"),
+	}
+
+	redacted := cfg.Redacted()
+	if redacted.FilePasswordFunc != nil {
+		t.Fatal("Expected FilePasswordFunc to be removed")
+	}
+	if cfg.FilePasswordFunc == nil {
+		t.Fatal("Expected the original Config to be unchanged")
+	}
+
+	s := fmt.Sprint(cfg)
+	expected := `keyring.Config{ServiceName: "llamas", FilePasswordFunc: set, FileDir: "~/.llamas"}`
+	if s != expected {
+		t.Fatalf("Expected %s, got %s", expected, s)
+	}
+	if strings.Contains(s, "no more secrets") {
+		t.Fatal("Expected the passphrase not to be printed")
+	}
+}
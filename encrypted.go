@@ -0,0 +1,42 @@
+package keyring
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidEncryptedExport is returned by ImportEncrypted when the input isn't a valid encrypted export.
+var ErrInvalidEncryptedExport = errors.New("The data is not a valid encrypted keyring export")
+
+// EncryptedExporter is implemented by backends that can copy their items
+// without decrypting them.
+type EncryptedExporter interface {
+	// Writes every item to w in its encrypted form
+	ExportEncrypted(w io.Writer) error
+	// Stores the items written by ExportEncrypted, replacing items with the same keys
+	ImportEncrypted(r io.Reader) error
+}
+
+// ExportEncrypted writes every item on kr to w still encrypted, so that a
+// backup can be taken without the passphrase. The export can only be read by
+// ImportEncrypted into the same kind of backend, and its items only with the
+// passphrase they were written with. It returns ErrNotSupported if the backend
+// doesn't implement EncryptedExporter.
+func ExportEncrypted(kr Keyring, w io.Writer) error {
+	if e, ok := as[EncryptedExporter](kr); ok {
+		return e.ExportEncrypted(w)
+	}
+	return ErrNotSupported
+}
+
+// ImportEncrypted restores the items written by ExportEncrypted onto kr,
+// replacing items with the same keys and leaving others alone. The whole
+// export is checked before anything is written, so an invalid export changes
+// nothing. It returns ErrNotSupported if the backend doesn't implement
+// EncryptedExporter.
+func ImportEncrypted(kr Keyring, r io.Reader) error {
+	if e, ok := as[EncryptedExporter](kr); ok {
+		return e.ImportEncrypted(r)
+	}
+	return ErrNotSupported
+}
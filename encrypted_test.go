@@ -0,0 +1,56 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFileKeyringEncryptedExport(t *testing.T) {
+	src := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	if err := src.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportEncrypted(&fileKeyring{dir: src.dir}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "llamas are great") {
+		t.Fatal("Expected the export to be encrypted")
+	}
+
+	dst := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	if err := ImportEncrypted(dst, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := dst.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected data %q", item.Data)
+	}
+}
+
+func TestFileKeyringImportEncryptedInvalid(t *testing.T) {
+	k := &fileKeyring{dir: t.TempDir()}
+
+	for _, export := range []string{
+		`not json`,
+		`[{"Key": "llamas", "Token": "not a token"}]`,
+		`[{"Key": "..", "Token": "a.b.c.d.e"}]`,
+	} {
+		err := ImportEncrypted(k, strings.NewReader(export))
+		if !errors.Is(err, ErrInvalidEncryptedExport) {
+			t.Fatalf("Expected ErrInvalidEncryptedExport for %s, got %v", export, err)
+		}
+	}
+
+	keys, _ := k.Keys()
+	if len(keys) != 0 {
+		t.Fatalf("Expected nothing to be imported, got %v", keys)
+	}
+}
@@ -0,0 +1,32 @@
+package keyring
+
+// RequiredEntitlements returns the permissions backend t needs beyond those of
+// an unconfined process, so that packaging tools can check an entitlements
+// plist, AppArmor profile, seccomp filter or Flatpak manifest. Identifiers are
+// prefixed by their kind:
+//
+//   - "dbus-session:" a well-known name the process must be able to talk to on the session bus
+//   - "syscall:" a system call the process must be allowed to make
+//   - "exec:" a program the process must be able to run
+//
+// The keychain backend needs no entitlement for the login keychain, including
+// from the App Sandbox, and wincred needs none either. The file backend and a
+// KeychainName keychain need read and write access to their files, which
+// depends on the Config rather than the backend, so it isn't listed.
+func (t BackendType) RequiredEntitlements() []string {
+	switch t {
+	case SecretServiceBackend:
+		return []string{"dbus-session:org.freedesktop.secrets"}
+	case KWalletBackend:
+		return []string{"dbus-session:org.kde.kwalletd5"}
+	case KeyCtlBackend:
+		return []string{"syscall:add_key", "syscall:keyctl"}
+	case PassBackend:
+		// pass runs gpg itself, see Config.PassCmd for a different pass
+		return []string{"exec:pass", "exec:gpg"}
+	case CLIBackend:
+		// security on macOS, secret-tool elsewhere
+		return []string{"exec:security", "exec:secret-tool"}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package keyring
+
+import "testing"
+
+func TestRequiredEntitlements(t *testing.T) {
+	if e := SecretServiceBackend.RequiredEntitlements(); len(e) != 1 || e[0] != "dbus-session:org.freedesktop.secrets" {
+		t.Fatalf("Unexpected secret-service entitlements %v", e)
+	}
+	if e := FileBackend.RequiredEntitlements(); len(e) != 0 {
+		t.Fatalf("Expected the file backend to need no entitlements, got %v", e)
+	}
+}
@@ -0,0 +1,49 @@
+package keyring
+
+import (
+	"errors"
+	"sort"
+)
+
+// EntriesLister is implemented by backends that can list the metadata of
+// every item in a single query.
+type EntriesLister interface {
+	// Returns the metadata of every item, sorted by key
+	Entries() ([]Metadata, error)
+}
+
+// Entries returns the metadata of every item on kr, sorted by key, so that a
+// UI can show a consistent list in one call. No item data is read, so it
+// doesn't prompt. Backends that implement EntriesLister take a single
+// snapshot; for the others it is Keys followed by GetMetadata for each key,
+// and items removed in between are left out. Where the backend has no
+// metadata without credentials, the entries only carry the key.
+func Entries(kr Keyring) ([]Metadata, error) {
+	if l, ok := as[EntriesLister](kr); ok {
+		return l.Entries()
+	}
+
+	keys, err := kr.Keys()
+	if err != nil && !errors.Is(err, ErrPartialResults) {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	entries := make([]Metadata, 0, len(keys))
+	for _, key := range keys {
+		md, err := kr.GetMetadata(key)
+		switch {
+		case err == ErrKeyNotFound:
+			continue
+		case errors.Is(err, ErrMetadataNotSupported), errors.Is(err, ErrMetadataNeedsCredentials):
+			md = Metadata{}
+		case err != nil:
+			return nil, err
+		}
+		if md.Item == nil {
+			md.Item = &Item{Key: key}
+		}
+		entries = append(entries, md)
+	}
+	return entries, nil
+}
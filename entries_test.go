@@ -0,0 +1,35 @@
+package keyring
+
+import "testing"
+
+func TestEntries(t *testing.T) {
+	k := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	for _, key := range []string{"llamas", "alpacas"} {
+		if err := k.Set(Item{Key: key, Label: key + " label", Data: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Entries(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "alpacas" || entries[1].Key != "llamas" {
+		t.Fatalf("Expected entries sorted by key, got %v", entries)
+	}
+	if entries[1].Label != "llamas label" || entries[1].ModificationTime.IsZero() {
+		t.Fatalf("Expected the metadata of llamas, got %+v", entries[1])
+	}
+}
+
+func TestEntriesWithoutMetadata(t *testing.T) {
+	k := NewArrayKeyring([]Item{{Key: "llamas"}, {Key: "alpacas"}})
+
+	entries, err := Entries(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "alpacas" || entries[1].Key != "llamas" {
+		t.Fatalf("Expected entries with just the keys, got %v", entries)
+	}
+}
@@ -0,0 +1,40 @@
+package keyring
+
+import "errors"
+
+// GetWithFallback returns the item stored under primary or, if there is none,
+// under the first of fallbacks that exists, along with the key it was found
+// under. It returns ErrKeyNotFound if none of the keys exist.
+func GetWithFallback(kr Keyring, primary string, fallbacks ...string) (Item, string, error) {
+	for _, key := range append([]string{primary}, fallbacks...) {
+		item, err := kr.Get(key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		} else if err != nil {
+			return Item{}, "", err
+		}
+		return item, key, nil
+	}
+	return Item{}, "", ErrKeyNotFound
+}
+
+// GetWithFallbackMigrate is like GetWithFallback, but when the item is found
+// under a fallback key it is moved to primary: stored under primary, then
+// removed from the fallback key. The returned Item has primary as its Key,
+// and the returned key is still the one it was found under.
+func GetWithFallbackMigrate(kr Keyring, primary string, fallbacks ...string) (Item, string, error) {
+	item, found, err := GetWithFallback(kr, primary, fallbacks...)
+	if err != nil || found == primary {
+		return item, found, err
+	}
+
+	debugf("Migrating %q to %q", found, primary)
+	item.Key = primary
+	if err = kr.Set(item); err != nil {
+		return Item{}, "", err
+	}
+	if err = kr.Remove(found); err != nil {
+		return Item{}, "", err
+	}
+	return item, found, nil
+}
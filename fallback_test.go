@@ -0,0 +1,43 @@
+package keyring
+
+import "testing"
+
+func TestGetWithFallback(t *testing.T) {
+	k := NewArrayKeyring([]Item{{Key: "old-llamas", Data: []byte("llamas are great")}})
+
+	item, found, err := GetWithFallback(k, "llamas", "older-llamas", "old-llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != "old-llamas" || string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected item %q found under %q", item.Data, found)
+	}
+
+	if _, _, err = GetWithFallback(k, "alpacas", "old-alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	// a wrapped ErrKeyNotFound moves on to the next key too
+	if _, found, err = GetWithFallback(wrappingKeyring{k}, "llamas", "old-llamas"); err != nil || found != "old-llamas" {
+		t.Fatalf("Expected the item under old-llamas, got %q, %v", found, err)
+	}
+}
+
+func TestGetWithFallbackMigrate(t *testing.T) {
+	k := NewArrayKeyring([]Item{{Key: "old-llamas", Data: []byte("llamas are great")}})
+
+	item, found, err := GetWithFallbackMigrate(k, "llamas", "old-llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != "old-llamas" || item.Key != "llamas" {
+		t.Fatalf("Unexpected item %q found under %q", item.Key, found)
+	}
+
+	if _, err = k.Get("old-llamas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected the fallback to be removed, got %v", err)
+	}
+	if _, found, err = GetWithFallback(k, "llamas"); err != nil || found != "llamas" {
+		t.Fatalf("Expected the item under the primary key, got %q, %v", found, err)
+	}
+}
@@ -2,33 +2,117 @@ package keyring
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"time"
 
 	jose "github.com/dvsekhvalnov/jose2go"
+	"github.com/dvsekhvalnov/jose2go/aes"
+	"github.com/dvsekhvalnov/jose2go/compact"
 	"github.com/mtibben/percent"
 )
 
 func init() {
-	supportedBackends[FileBackend] = opener(func(cfg Config) (Keyring, error) {
-		return &fileKeyring{
-			dir:          cfg.FileDir,
-			passwordFunc: cfg.FilePasswordFunc,
-		}, nil
+	supportedBackends[FileBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
+		k := &fileKeyring{
+			dir:            cfg.FileDir,
+			passwordFunc:   cfg.FilePasswordFunc,
+			codec:          cfg.FileCodec,
+			keepVersions:   cfg.FileKeepVersions,
+			maxAttempts:    cfg.FilePasswordMaxAttempts,
+			noSyncOnWrite:  cfg.FileNoSyncOnWrite,
+			constantTime:   cfg.ConstantTimeLookups,
+			allowEmptyKeys: cfg.AllowEmptyKeys,
+		}
+
+		if err := k.checkPermissions(); err != nil {
+			if cfg.FileStrictPermissions {
+				return nil, err
+			}
+			debugf("Warning: %v", err)
+		}
+
+		return k, nil
 	})
 }
 
+// ErrInsecurePermissions is returned when the file backend's directory or files are accessible by other users.
+var ErrInsecurePermissions = errors.New("The keyring files are accessible by other users")
+
+// ErrMaxPasswordAttempts is returned when every one of Config.FilePasswordMaxAttempts passphrases was wrong.
+var ErrMaxPasswordAttempts = errors.New("The passphrase was wrong too many times")
+
+// emptyKeyFilename is the name of the file holding the item with an empty
+// key, as allowed by Config.AllowEmptyKeys. percent.Encode never produces a
+// lone "%", as it escapes "%" itself.
+const emptyKeyFilename = "%"
+
+// filenameEscape returns the name of the file holding key. The keys "", "."
+// and ".." are escaped too, as they would otherwise name the directory.
 var filenameEscape = func(s string) string {
+	switch s {
+	case "":
+		return emptyKeyFilename
+	case ".", "..":
+		return percent.Encode(s, ".")
+	}
 	return percent.Encode(s, "/")
 }
-var filenameUnescape = percent.Decode
+
+var filenameUnescape = func(s string) string {
+	if s == emptyKeyFilename {
+		return ""
+	}
+	return percent.Decode(s)
+}
+
+// The label and description of an item are copied into these fields of the
+// JWE protected header, so that GetMetadata can return them without the
+// passphrase. The header is not encrypted; anyone who can read the file can
+// read them, but it is authenticated, so tampering makes Get fail.
+const (
+	fileHeaderLabel       = "label"
+	fileHeaderDescription = "description"
+	fileHeaderCategory    = "category"
+)
+
+// fileHeaderCodec is the JWE protected header field naming the FileCodec of the payload.
+const fileHeaderCodec = "codec"
+
+// fileVersionsDir is the directory in the keyring directory that keeps the
+// replaced files of each key when FileKeepVersions is set, in a directory per
+// key named like its item file. An escaped key never contains a % that isn't
+// followed by two hex digits, so it can't clash with an item file.
+const fileVersionsDir = "%versions"
 
 type fileKeyring struct {
-	dir          string
-	passwordFunc PromptFunc
-	password     string
+	dir            string
+	passwordFunc   PromptFunc
+	password       string
+	codec          FileCodec
+	keepVersions   int
+	maxAttempts    int
+	noSyncOnWrite  bool
+	constantTime   bool
+	allowEmptyKeys bool
+
+	// decoy is a token encrypted with decoyPassword that Get decrypts for
+	// missing keys when constantTime is set
+	decoy         string
+	decoyPassword string
+}
+
+func (k *fileKeyring) fileCodec() FileCodec {
+	if k.codec == nil {
+		return JSONFileCodec
+	}
+	return k.codec
 }
 
 func (k *fileKeyring) resolveDir() (string, error) {
@@ -51,6 +135,73 @@ func (k *fileKeyring) resolveDir() (string, error) {
 	return dir, err
 }
 
+// checkProbe checks what CandidateBackends needs beyond the opener: a
+// FilePasswordFunc, and a FileDir that is a directory or doesn't exist yet.
+// Unlike resolveDir it doesn't create the directory.
+func (k *fileKeyring) checkProbe() error {
+	if k.passwordFunc == nil {
+		return errors.New("No password function provided for file keyring")
+	}
+	if k.dir == "" {
+		return errors.New("No directory provided for file keyring")
+	}
+
+	dir, err := ExpandTilde(k.dir)
+	if err != nil {
+		return err
+	}
+
+	stat, err := os.Stat(dir)
+	if err == nil && !stat.IsDir() {
+		return fmt.Errorf("%s is a file, not a directory", dir)
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// checkPermissions returns an error wrapping ErrInsecurePermissions if the
+// existing directory or any file in it can be accessed by the group or others.
+// The directory is created 0700 and files 0600, so this only fails for files
+// created or changed outside of keyring. Windows doesn't use these modes, so
+// it isn't checked there.
+func (k *fileKeyring) checkPermissions() error {
+	if runtime.GOOS == "windows" || k.dir == "" {
+		return nil
+	}
+
+	dir, err := ExpandTilde(k.dir)
+	if err != nil {
+		return err
+	}
+
+	stat, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if perm := stat.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("%w: %s has mode %04o", ErrInsecurePermissions, dir, perm)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil {
+			return err
+		}
+		if perm := info.Mode().Perm(); perm&0077 != 0 {
+			return fmt.Errorf("%w: %s has mode %04o", ErrInsecurePermissions, filepath.Join(dir, f.Name()), perm)
+		}
+	}
+
+	return nil
+}
+
 func (k *fileKeyring) unlock() error {
 	dir, err := k.resolveDir()
 	if err != nil {
@@ -68,7 +219,39 @@ func (k *fileKeyring) unlock() error {
 	return nil
 }
 
+// Unlock prompts for the passphrase now and checks it by decrypting one of the
+// items, forgetting it again if it's wrong. Each item has its own salt, so it's
+// the passphrase that is kept for later operations rather than a derived key.
+func (k *fileKeyring) Unlock() error {
+	if err := k.unlock(); err != nil {
+		return err
+	}
+
+	keys, err := k.Keys()
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	filename, err := k.filename(keys[0])
+	if err != nil {
+		return err
+	}
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, err = k.decode(bytes); err != nil && isWrongPassphrase(err) {
+		k.password = ""
+	}
+	return err
+}
+
 func (k *fileKeyring) Get(key string) (Item, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
 	filename, err := k.filename(key)
 	if err != nil {
 		return Item{}, err
@@ -76,32 +259,112 @@ func (k *fileKeyring) Get(key string) (Item, error) {
 
 	bytes, err := os.ReadFile(filename)
 	if os.IsNotExist(err) {
+		if k.constantTime {
+			k.decodeDecoy()
+		}
 		return Item{}, ErrKeyNotFound
 	} else if err != nil {
 		return Item{}, err
 	}
 
-	if err = k.unlock(); err != nil {
-		return Item{}, err
+	return k.decode(bytes)
+}
+
+// decodeDecoy decrypts a decoy token, so that a Get for a missing key takes
+// about as long as one for an existing key, whose cost is mostly the key
+// derivation. It prompts for the passphrase like Get does for an existing key.
+// The first call, and the first after the passphrase changes, also encrypts
+// the decoy, which costs as much again.
+func (k *fileKeyring) decodeDecoy() {
+	if err := k.unlock(); err != nil {
+		return
+	}
+
+	if k.decoy == "" || k.decoyPassword != k.password {
+		decoy, err := jose.EncryptBytes([]byte("{}"), jose.PBES2_HS256_A128KW, jose.A256GCM, k.password)
+		if err != nil {
+			return
+		}
+		k.decoy, k.decoyPassword = decoy, k.password
+	}
+	_, _, _ = jose.DecodeBytes(k.decoy, k.password)
+}
+
+func (k *fileKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
+// errKeyUnwrap is the error jose2go's key unwrap returns when the integrity
+// check fails, taken from the library rather than copied so a change to its
+// wording can't silently turn wrong passphrases into fatal errors. Unwrapping
+// zeros with a zero key fails that check.
+var errKeyUnwrap = func() error {
+	_, err := aes.KeyUnwrap(make([]byte, 24), make([]byte, 16))
+	return err
+}()
+
+// isWrongPassphrase reports whether err from jose.DecodeBytes is the failed
+// unwrap of the content key, which is what a wrong passphrase causes. jose
+// returns that error as is. A corrupt file fails to parse or to authenticate
+// its content instead.
+func isWrongPassphrase(err error) bool {
+	return errKeyUnwrap != nil && err.Error() == errKeyUnwrap.Error()
+}
+
+// decode decrypts the contents of an item file. With maxAttempts above 1, a
+// wrong passphrase is forgotten and prompted for again, up to maxAttempts
+// times in all.
+func (k *fileKeyring) decode(bytes []byte) (Item, error) {
+	var payload []byte
+	var header map[string]interface{}
+	for attempt := 1; ; attempt++ {
+		if err := k.unlock(); err != nil {
+			return Item{}, err
+		}
+
+		var err error
+		payload, header, err = jose.DecodeBytes(string(bytes), k.password)
+		if err == nil {
+			break
+		}
+		if k.maxAttempts <= 1 || !isWrongPassphrase(err) {
+			return Item{}, err
+		}
+
+		debugf("Wrong passphrase, attempt %d of %d", attempt, k.maxAttempts)
+		k.password = ""
+		if attempt >= k.maxAttempts {
+			return Item{}, fmt.Errorf("%w: %v", ErrMaxPasswordAttempts, err)
+		}
 	}
 
-	payload, _, err := jose.Decode(string(bytes), k.password)
+	codecName, _ := header[fileHeaderCodec].(string)
+	codec, err := fileCodecFor(codecName, k.fileCodec())
 	if err != nil {
 		return Item{}, err
 	}
 
 	var decoded Item
-	err = json.Unmarshal([]byte(payload), &decoded)
+	err = codec.Unmarshal(payload, &decoded)
 
 	return decoded, err
 }
 
 func (k *fileKeyring) GetMetadata(key string) (Metadata, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Metadata{}, err
+	}
+
 	filename, err := k.filename(key)
 	if err != nil {
 		return Metadata{}, err
 	}
 
+	return fileMetadata(key, filename)
+}
+
+// fileMetadata reads the metadata of the item with the given key from filename.
+func fileMetadata(key, filename string) (Metadata, error) {
 	stat, err := os.Stat(filename)
 	if os.IsNotExist(err) {
 		return Metadata{}, ErrKeyNotFound
@@ -109,41 +372,220 @@ func (k *fileKeyring) GetMetadata(key string) (Metadata, error) {
 		return Metadata{}, err
 	}
 
-	// For the File provider, all internal data is encrypted, not just the
-	// credentials.  Thus we only have the timestamps.  Return a nil *Item.
-	//
-	// If we want to change this ... how portable are extended file attributes
-	// these days?  Would it break user expectations of the security model to
-	// leak data into those?  I'm hesitant to do so.
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	header, err := fileHeader(string(bytes))
+	if err != nil {
+		return Metadata{}, err
+	}
 
-	return Metadata{
+	md := Metadata{
 		ModificationTime: stat.ModTime(),
-	}, nil
+	}
+
+	// Files written before the label was copied into the header only have
+	// the timestamps, so return a nil *Item for them. They are upgraded the
+	// next time the item is Set.
+	if label, ok := header[fileHeaderLabel].(string); ok {
+		description, _ := header[fileHeaderDescription].(string)
+		category, _ := header[fileHeaderCategory].(string)
+		md.Item = &Item{
+			Key:         key,
+			Label:       label,
+			Description: description,
+			Category:    category,
+		}
+	}
+
+	return md, nil
+}
+
+// fileHeader returns the JWE protected header of token without decrypting it.
+func fileHeader(token string) (map[string]interface{}, error) {
+	parts, err := compact.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var header map[string]interface{}
+	err = json.Unmarshal(parts[0], &header)
+	return header, err
 }
 
 func (k *fileKeyring) Set(i Item) error {
-	bytes, err := json.Marshal(i)
+	return k.SetWithOptions(i)
+}
+
+func (k *fileKeyring) SetWithOptions(i Item, opts ...SetOption) error {
+	_, err := k.set(i, newSetOptions(opts))
+	return err
+}
+
+// SetResult reports whether the item's file existed before it was written.
+func (k *fileKeyring) SetResult(i Item) (SetOutcome, error) {
+	return k.set(i, setOptions{})
+}
+
+func (k *fileKeyring) set(i Item, o setOptions) (SetOutcome, error) {
+	if err := checkKey(i.Key, k.allowEmptyKeys); err != nil {
+		return 0, err
+	}
+
+	codec := k.fileCodec()
+
+	bytes, err := codec.Marshal(i)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if err = k.unlock(); err != nil {
-		return err
+		return 0, err
 	}
 
-	token, err := jose.Encrypt(string(bytes), jose.PBES2_HS256_A128KW, jose.A256GCM, k.password,
+	token, err := jose.EncryptBytes(bytes, jose.PBES2_HS256_A128KW, jose.A256GCM, k.password,
 		jose.Headers(map[string]interface{}{
-			"created": time.Now().String(),
+			"created":             time.Now().String(),
+			fileHeaderLabel:       i.Label,
+			fileHeaderDescription: i.Description,
+			fileHeaderCategory:    i.Category,
+			fileHeaderCodec:       codec.Name(),
 		}))
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	filename, err := k.filename(i.Key)
+	if err != nil {
+		return 0, err
+	}
+
+	if !o.createOnly {
+		outcome := SetCreated
+		if _, err := os.Stat(filename); err == nil {
+			outcome = SetUpdated
+		}
+		if outcome == SetUpdated && k.keepVersions > 0 {
+			return outcome, k.replaceKeepingVersion(i.Key, filename, []byte(token))
+		}
+		return outcome, k.writeFile(filename, []byte(token), os.O_TRUNC)
+	}
+
+	err = k.writeFile(filename, []byte(token), os.O_EXCL)
+	if os.IsExist(err) {
+		return 0, ErrKeyExists
+	} else if err != nil {
+		return 0, err
+	}
+	return SetCreated, nil
+}
+
+// writeFile creates or opens filename with the extra flag, either os.O_TRUNC
+// or os.O_EXCL, and writes data to it. Unless noSyncOnWrite is set, the file
+// and then the directory are synced, so that the item survives a crash once
+// Set returns.
+func (k *fileKeyring) writeFile(filename string, data []byte, flag int) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|flag, 0600)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filename, []byte(token), 0600)
+	_, err = f.Write(data)
+	if err == nil && !k.noSyncOnWrite {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || k.noSyncOnWrite {
+		return err
+	}
+	return syncDir(filepath.Dir(filename))
+}
+
+// syncDir syncs the directory entries of dir. Windows can't open directories
+// for syncing and persists them with the file, so it is skipped there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Sync syncs every item file and then the keyring directory, for writes made
+// with FileNoSyncOnWrite set.
+func (k *fileKeyring) Sync() error {
+	dir, err := k.resolveDir()
+	if err != nil {
+		return err
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(dir, file.Name()), os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		err = f.Sync()
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return syncDir(dir)
+}
+
+// KeysByCategory reads the category from each file's header, so it doesn't
+// need the passphrase. Files written before categories were added to the
+// header are treated as having none.
+func (k *fileKeyring) KeysByCategory(category string) ([]string, error) {
+	keys, err := k.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []string{}
+	for _, key := range keys {
+		md, err := k.GetMetadata(key)
+		if err != nil {
+			return nil, err
+		}
+		if md.Item != nil && md.Category == category {
+			matched = append(matched, key)
+		}
+	}
+	return matched, nil
+}
+
+func (k *fileKeyring) Touch(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
+	filename, err := k.filename(key)
+	if err != nil {
+		return err
+	}
+
+	return touchFile(filename)
 }
 
 func (k *fileKeyring) filename(key string) (string, error) {
@@ -156,12 +598,24 @@ func (k *fileKeyring) filename(key string) (string, error) {
 }
 
 func (k *fileKeyring) Remove(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	filename, err := k.filename(key)
 	if err != nil {
 		return err
 	}
 
-	return os.Remove(filename)
+	if err := os.Remove(filename); err != nil {
+		return err
+	}
+
+	versionsDir, err := k.versionsDir(key)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(versionsDir)
 }
 
 func (k *fileKeyring) Keys() ([]string, error) {
@@ -173,8 +627,299 @@ func (k *fileKeyring) Keys() ([]string, error) {
 	var keys = []string{}
 	files, _ := os.ReadDir(dir)
 	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
 		keys = append(keys, filenameUnescape(f.Name()))
 	}
 
 	return keys, nil
 }
+
+func (k *fileKeyring) BackendType() BackendType {
+	return FileBackend
+}
+
+// RemoveAll deletes every item file from the keyring directory.
+func (k *fileKeyring) RemoveAll() error {
+	_, err := k.RemoveMatching(func(string) bool { return true })
+	return err
+}
+
+// fileExportItem is an item file as written by ExportEncrypted.
+type fileExportItem struct {
+	Key   string
+	Token string
+}
+
+// ExportEncrypted writes the JWE token of every item file to w as JSON,
+// ordered by key. The tokens are copied verbatim, so no passphrase is needed.
+func (k *fileKeyring) ExportEncrypted(w io.Writer) error {
+	keys, err := k.Keys()
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+
+	items := make([]fileExportItem, 0, len(keys))
+	for _, key := range keys {
+		filename, err := k.filename(key)
+		if err != nil {
+			return err
+		}
+		bytes, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		items = append(items, fileExportItem{Key: key, Token: string(bytes)})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// ImportEncrypted writes the tokens from ExportEncrypted back to item files,
+// after checking that each one has the header of a file keyring token.
+func (k *fileKeyring) ImportEncrypted(r io.Reader) error {
+	var items []fileExportItem
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncryptedExport, err)
+	}
+
+	for _, i := range items {
+		if err := checkKey(i.Key, k.allowEmptyKeys); err != nil {
+			return fmt.Errorf("%w: invalid key %q: %v", ErrInvalidEncryptedExport, i.Key, err)
+		}
+		header, err := fileHeader(i.Token)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidEncryptedExport, i.Key, err)
+		}
+		if header["alg"] != jose.PBES2_HS256_A128KW || header["enc"] != jose.A256GCM {
+			return fmt.Errorf("%w: %s is not encrypted like a file keyring item", ErrInvalidEncryptedExport, i.Key)
+		}
+	}
+
+	for _, i := range items {
+		filename, err := k.filename(i.Key)
+		if err != nil {
+			return err
+		}
+		if err := k.writeFile(filename, []byte(i.Token), os.O_TRUNC); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset deletes every file in the keyring directory and then the directory
+// itself, and forgets the cached passphrase so that the next Set can choose a
+// new one. Only the files directly in the directory are removed; the directory
+// is left in place if it contains anything else.
+func (k *fileKeyring) Reset() error {
+	if k.dir == "" {
+		return fmt.Errorf("No directory provided for file keyring")
+	}
+
+	dir, err := ExpandTilde(k.dir)
+	if err != nil {
+		return err
+	}
+
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(filepath.Join(dir, fileVersionsDir)); err != nil {
+		return err
+	}
+
+	k.password = ""
+	if err := os.Remove(dir); err != nil {
+		debugf("Keeping keyring directory %s: %v", dir, err)
+	}
+	return nil
+}
+
+// RemoveMatching deletes the item files whose key satisfies pred.
+func (k *fileKeyring) RemoveMatching(pred func(key string) bool) (int, error) {
+	return removeMatching(k, pred)
+}
+
+func (k *fileKeyring) versionsDir(key string) (string, error) {
+	dir, err := k.resolveDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, fileVersionsDir, filenameEscape(key)), nil
+}
+
+// versionFiles returns the kept files of the item with the given key, newest
+// first. They are named by a sequence number that increases with each Set.
+func (k *fileKeyring) versionFiles(key string) ([]string, []int, error) {
+	versionsDir, err := k.versionsDir(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, err := os.ReadDir(versionsDir)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	seqs := []int{}
+	for _, f := range files {
+		if seq, err := strconv.Atoi(f.Name()); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(seqs)))
+
+	filenames := make([]string, len(seqs))
+	for i, seq := range seqs {
+		filenames[i] = filepath.Join(versionsDir, strconv.Itoa(seq))
+	}
+	return filenames, seqs, nil
+}
+
+// fileVersionPending is the name, in an item's versions directory, of the
+// new contents of the item while they are written. versionFiles ignores it,
+// as it isn't a number.
+const fileVersionPending = "pending"
+
+// replaceKeepingVersion replaces the item file with data and keeps the
+// previous contents as the newest version. data is written to a pending file
+// first, and only once that succeeded is the previous file linked into the
+// versions and the pending file renamed over it, so that a failed write
+// leaves the item as it was.
+func (k *fileKeyring) replaceKeepingVersion(key, filename string, data []byte) error {
+	versionsDir, err := k.versionsDir(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(versionsDir, 0700); err != nil {
+		return err
+	}
+
+	pending := filepath.Join(versionsDir, fileVersionPending)
+	if err := k.writeFile(pending, data, os.O_TRUNC); err != nil {
+		_ = os.Remove(pending)
+		return err
+	}
+
+	filenames, seqs, err := k.versionFiles(key)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(seqs) > 0 {
+		next = seqs[0] + 1
+	}
+	if err := k.copyVersion(filename, filepath.Join(versionsDir, strconv.Itoa(next))); err != nil {
+		_ = os.Remove(pending)
+		return err
+	}
+
+	if err := os.Rename(pending, filename); err != nil {
+		return err
+	}
+	if !k.noSyncOnWrite {
+		if err := syncDir(filepath.Dir(filename)); err != nil {
+			return err
+		}
+	}
+
+	// the previous contents are now the newest kept version
+	for i := k.keepVersions - 1; i < len(filenames); i++ {
+		if err := os.Remove(filenames[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyVersion keeps the item file as version, with its modification time,
+// which Versions reports. It hard links the file, which is safe as the item
+// file is then replaced rather than written in place, or copies it where
+// links aren't supported.
+func (k *fileKeyring) copyVersion(filename, version string) error {
+	if err := os.Link(filename, version); err == nil {
+		if k.noSyncOnWrite {
+			return nil
+		}
+		return syncDir(filepath.Dir(version))
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := k.writeFile(version, data, os.O_EXCL); err != nil {
+		return err
+	}
+	return os.Chtimes(version, info.ModTime(), info.ModTime())
+}
+
+// GetVersion decrypts version n of the item, which needs the passphrase like Get.
+func (k *fileKeyring) GetVersion(key string, n int) (Item, error) {
+	if n == 0 {
+		return k.Get(key)
+	}
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
+	filenames, _, err := k.versionFiles(key)
+	if err != nil {
+		return Item{}, err
+	}
+	if n < 0 || n > len(filenames) {
+		return Item{}, ErrKeyNotFound
+	}
+
+	bytes, err := os.ReadFile(filenames[n-1])
+	if err != nil {
+		return Item{}, err
+	}
+	return k.decode(bytes)
+}
+
+// Versions reads the header of each kept file, so it doesn't need the passphrase.
+func (k *fileKeyring) Versions(key string) ([]Metadata, error) {
+	current, err := k.GetMetadata(key)
+	if err != nil {
+		return nil, err
+	}
+
+	filenames, _, err := k.versionFiles(key)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []Metadata{current}
+	for _, filename := range filenames {
+		md, err := fileMetadata(key, filename)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, md)
+	}
+	return versions, nil
+}
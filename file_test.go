@@ -1,8 +1,15 @@
 package keyring
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+
+	jose "github.com/dvsekhvalnov/jose2go"
 )
 
 func TestFileKeyringSetWhenEmpty(t *testing.T) {
@@ -59,3 +66,285 @@ func TestFilenameWithBadChars(t *testing.T) {
 		t.Fatal("Unexpected filenameEscape")
 	}
 }
+
+func TestFileKeyringGetMetadataWithoutPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	k := &fileKeyring{
+		dir:          dir,
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	item := Item{Key: "llamas", Label: "Llamas", Description: "A llama", Data: []byte("llamas are great")}
+
+	if err := k.Set(item); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := &fileKeyring{
+		dir: dir,
+		passwordFunc: func(string) (string, error) {
+			t.Fatal("GetMetadata asked for the passphrase")
+			return "", nil
+		},
+	}
+
+	md, err := locked.GetMetadata("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Item == nil {
+		t.Fatal("Expected item metadata")
+	}
+	if md.Key != item.Key || md.Label != item.Label || md.Description != item.Description {
+		t.Fatalf("Unexpected metadata: %#v", md.Item)
+	}
+	if len(md.Data) != 0 {
+		t.Fatalf("Metadata leaked the data: %q", md.Data)
+	}
+}
+
+func TestFileKeyringPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File modes aren't enforced on Windows")
+	}
+
+	dir := filepath.Join(t.TempDir(), "keyring")
+	k, err := Open(Config{
+		AllowedBackends:       []BackendType{FileBackend},
+		FileDir:               dir,
+		FilePasswordFunc:      FixedStringPrompt("no more secrets"),
+		FileStrictPermissions: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]os.FileMode{dir: 0700, filepath.Join(dir, "llamas"): 0600} {
+		stat, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := stat.Mode().Perm(); perm != want {
+			t.Fatalf("Expected %s to have mode %04o, got %04o", path, want, perm)
+		}
+	}
+
+	if err = os.Chmod(filepath.Join(dir, "llamas"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Open(Config{
+		RequireBackend:        FileBackend,
+		FileDir:               dir,
+		FileStrictPermissions: true,
+	})
+	if !errors.Is(err, ErrInsecurePermissions) {
+		t.Fatalf("Expected ErrInsecurePermissions, got %v", err)
+	}
+
+	if _, err = Open(Config{RequireBackend: FileBackend, FileDir: dir}); err != nil {
+		t.Fatalf("Expected only a warning without strict permissions, got %v", err)
+	}
+}
+
+// sequencePrompt returns each of passwords in turn and counts the prompts.
+func sequencePrompt(prompts *int, passwords ...string) PromptFunc {
+	return func(string) (string, error) {
+		password := passwords[*prompts%len(passwords)]
+		*prompts++
+		return password, nil
+	}
+}
+
+func TestFileKeyringRetriesWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	writer := &fileKeyring{dir: dir, passwordFunc: FixedStringPrompt("no more secrets")}
+	if err := writer.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := 0
+	k := &fileKeyring{
+		dir:          dir,
+		passwordFunc: sequencePrompt(&prompts, "wrong", "also wrong", "no more secrets"),
+		maxAttempts:  3,
+	}
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" || prompts != 3 {
+		t.Fatalf("Expected the item after 3 prompts, got %q after %d", item.Data, prompts)
+	}
+
+	prompts = 0
+	k = &fileKeyring{
+		dir:          dir,
+		passwordFunc: sequencePrompt(&prompts, "wrong"),
+		maxAttempts:  2,
+	}
+	if _, err := k.Get("llamas"); !errors.Is(err, ErrMaxPasswordAttempts) {
+		t.Fatalf("Expected ErrMaxPasswordAttempts, got %v", err)
+	}
+	if prompts != 2 {
+		t.Fatalf("Expected 2 prompts, got %d", prompts)
+	}
+}
+
+func TestFileKeyringCorruptFileFailsFast(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "llamas"), []byte("not a token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := 0
+	k := &fileKeyring{
+		dir:          dir,
+		passwordFunc: sequencePrompt(&prompts, "no more secrets"),
+		maxAttempts:  3,
+	}
+	_, err := k.Get("llamas")
+	if err == nil || errors.Is(err, ErrMaxPasswordAttempts) {
+		t.Fatalf("Expected a decoding error, got %v", err)
+	}
+	if prompts != 1 {
+		t.Fatalf("Expected a single prompt, got %d", prompts)
+	}
+}
+
+func TestIsWrongPassphrase(t *testing.T) {
+	token, err := jose.EncryptBytes([]byte("{}"), jose.PBES2_HS256_A128KW, jose.A256GCM, "right")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = jose.DecodeBytes(token, "wrong"); err == nil || !isWrongPassphrase(err) {
+		t.Fatalf("Expected a wrong passphrase error, got %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	parts[3] = parts[4]
+	if _, _, err = jose.DecodeBytes(strings.Join(parts, "."), "right"); err == nil || isWrongPassphrase(err) {
+		t.Fatalf("Expected tampered content not to look like a wrong passphrase, got %v", err)
+	}
+
+	if _, _, err = jose.DecodeBytes("not a token", "right"); err == nil || isWrongPassphrase(err) {
+		t.Fatalf("Expected a corrupt token not to look like a wrong passphrase, got %v", err)
+	}
+}
+
+func TestFileKeyringConstantTimeLookups(t *testing.T) {
+	prompts := 0
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: sequencePrompt(&prompts, "no more secrets"),
+		constantTime: true,
+	}
+
+	if _, err := k.Get("llamas"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if k.decoy == "" {
+		t.Fatal("Expected a decoy to be decrypted for the missing key")
+	}
+	if prompts != 1 {
+		t.Fatalf("Expected one prompt, got %d", prompts)
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Get returned %q", item.Data)
+	}
+}
+
+// BenchmarkFileKeyringGet compares Get for present and missing keys, with
+// and without ConstantTimeLookups. Each iteration is dominated by the PBES2
+// key derivation, which ConstantTimeLookups also does for missing keys.
+func BenchmarkFileKeyringGet(b *testing.B) {
+	for _, constantTime := range []bool{false, true} {
+		k := &fileKeyring{
+			dir:          b.TempDir(),
+			passwordFunc: FixedStringPrompt("no more secrets"),
+			constantTime: constantTime,
+		}
+		if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+			b.Fatal(err)
+		}
+
+		for _, key := range []string{"llamas", "alpacas"} {
+			b.Run(fmt.Sprintf("constantTime=%v/key=%s", constantTime, key), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = k.Get(key)
+				}
+			})
+		}
+	}
+}
+
+func TestFileKeyringUnlock(t *testing.T) {
+	dir := t.TempDir()
+	k := &fileKeyring{
+		dir:          dir,
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := 0
+	k = &fileKeyring{
+		dir:          dir,
+		passwordFunc: sequencePrompt(&prompts, "no more secrets"),
+	}
+	if err := k.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 1 {
+		t.Fatalf("Expected Unlock to prompt once, got %d", prompts)
+	}
+	if _, err := k.Get("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 1 {
+		t.Fatalf("Expected Get not to prompt again, got %d prompts", prompts)
+	}
+}
+
+func TestFileKeyringUnlockWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	k := &fileKeyring{
+		dir:          dir,
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := 0
+	k = &fileKeyring{
+		dir:          dir,
+		passwordFunc: sequencePrompt(&prompts, "wrong", "no more secrets"),
+	}
+	if err := k.Unlock(); err == nil {
+		t.Fatal("Expected Unlock to fail with the wrong passphrase")
+	}
+	if k.password != "" {
+		t.Fatal("Expected the wrong passphrase to be forgotten")
+	}
+
+	if _, err := k.Get("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 2 {
+		t.Fatalf("Expected Get to prompt again, got %d prompts", prompts)
+	}
+}
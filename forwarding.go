@@ -0,0 +1,46 @@
+package keyring
+
+// forwarding passes the optional interfaces that don't take or return keys
+// to the decorated Keyring. It is embedded by decorators that change keys or
+// items, such as caseInsensitiveKeyring, which can't implement Unwrapper as
+// the helpers would then bypass them for the interfaces that do involve keys.
+// Those they implement themselves, or leave to the helpers' fallbacks.
+type forwarding struct {
+	Keyring
+}
+
+func (f forwarding) BackendType() BackendType {
+	return BackendTypeOf(f.Keyring)
+}
+
+func (f forwarding) Sync() error {
+	return Sync(f.Keyring)
+}
+
+func (f forwarding) Unlock() error {
+	return Unlock(f.Keyring)
+}
+
+func (f forwarding) Reset() error {
+	return Reset(f.Keyring)
+}
+
+func (f forwarding) MaxValueSize() int {
+	return MaxValueSize(f.Keyring)
+}
+
+func (f forwarding) DeduplicateSynchronizable(preferSynchronizable bool) (int, error) {
+	return DeduplicateSynchronizable(f.Keyring, preferSynchronizable)
+}
+
+func (f forwarding) RecoverItems(filter func(attrs ItemAttributes) bool) ([]Item, error) {
+	return RecoverItems(f.Keyring, filter)
+}
+
+func (f forwarding) GetRawTarget(target string) (Item, error) {
+	return GetRawTarget(f.Keyring, target)
+}
+
+func (f forwarding) ListAllTargets() ([]string, error) {
+	return ListAllTargets(f.Keyring)
+}
@@ -0,0 +1,57 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// healthCheckKeyPrefix starts the key of the probe item written by HealthCheck.
+const healthCheckKeyPrefix = "__keyring_healthcheck__"
+
+// HealthCheck exercises kr by writing a probe item, reading it back and
+// removing it, e.g. for a readiness probe. The probe's key has a random
+// suffix so that it can't collide with real items, and the probe is removed
+// even when a later step fails. It returns an error naming the step that
+// failed, wrapping ErrWriteVerificationFailed if the data read back differs.
+//
+// Like any write, the check may prompt the user on backends that do.
+func HealthCheck(kr Keyring) (err error) {
+	suffix := make([]byte, 8)
+	if _, err = rand.Read(suffix); err != nil {
+		return err
+	}
+	data := make([]byte, 16)
+	if _, err = rand.Read(data); err != nil {
+		return err
+	}
+
+	probe := Item{
+		Key:   healthCheckKeyPrefix + hex.EncodeToString(suffix),
+		Data:  data,
+		Label: "keyring health check",
+	}
+
+	if err = kr.Set(probe); err != nil {
+		// the write may have stored the item before failing
+		_ = kr.Remove(probe.Key)
+		return fmt.Errorf("health check failed to write %q: %w", probe.Key, err)
+	}
+
+	defer func() {
+		if removeErr := kr.Remove(probe.Key); removeErr != nil && err == nil {
+			err = fmt.Errorf("health check failed to remove %q: %w", probe.Key, removeErr)
+		}
+	}()
+
+	stored, err := kr.Get(probe.Key)
+	if err != nil {
+		return fmt.Errorf("health check failed to read %q: %w", probe.Key, err)
+	}
+	if !bytes.Equal(stored.Data, probe.Data) {
+		return fmt.Errorf("health check read back %q: %w", probe.Key, ErrWriteVerificationFailed)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+	k := NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}})
+	if err := HealthCheck(k); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, _ := k.Keys()
+	if len(keys) != 1 || keys[0] != "llamas" {
+		t.Fatalf("Expected the probe to be removed, got %v", keys)
+	}
+}
+
+// corruptingKeyring returns different data from what was written.
+type corruptingKeyring struct {
+	*ArrayKeyring
+}
+
+func (k corruptingKeyring) Get(key string) (Item, error) {
+	item, err := k.ArrayKeyring.Get(key)
+	item.Data = []byte("corrupted")
+	return item, err
+}
+
+func TestHealthCheckVerifiesData(t *testing.T) {
+	inner := NewArrayKeyring(nil)
+	if err := HealthCheck(corruptingKeyring{inner}); !errors.Is(err, ErrWriteVerificationFailed) {
+		t.Fatalf("Expected ErrWriteVerificationFailed, got %v", err)
+	}
+
+	keys, _ := inner.Keys()
+	if len(keys) != 0 {
+		t.Fatalf("Expected the probe to be removed, got %v", keys)
+	}
+}
@@ -0,0 +1,154 @@
+package keyring
+
+import (
+	"errors"
+	"time"
+)
+
+// ConflictPolicy decides what Import does with a key that is already on the destination keyring.
+type ConflictPolicy int
+
+const (
+	// ConflictSkip keeps the existing item
+	ConflictSkip ConflictPolicy = iota
+	// ConflictOverwrite replaces the existing item
+	ConflictOverwrite
+	// ConflictNewest keeps whichever item has the later ModificationTime, or
+	// CreationTime for items without one. If either backend can't provide
+	// metadata, or either item has neither time, it behaves like
+	// ConflictOverwrite.
+	// Other errors reading the metadata, e.g. ErrLocked, are reported for the
+	// key, which is left alone
+	ConflictNewest
+)
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	// OnConflict is the policy for keys that already exist on the destination
+	OnConflict ConflictPolicy
+
+	// DryRun reports what Import would do without writing anything
+	DryRun bool
+}
+
+// ImportResult lists the keys Import added, overwrote, skipped or failed on.
+type ImportResult struct {
+	Added       []string
+	Overwritten []string
+	Skipped     []string
+	Errors      map[string]error
+}
+
+// Import copies every item from src to dst, resolving keys that exist on both
+// according to opts. Failures for individual keys are collected in the
+// result's Errors; the returned error is only set if the keys of either
+// keyring can't be listed. Keys that src can't list, as reported by
+// ErrPartialResults, aren't imported. When dst can't list all of its keys,
+// a key missing from its list is looked up with GetMetadata and treated as
+// existing unless that returns ErrKeyNotFound.
+func Import(dst, src Keyring, opts ImportOptions) (ImportResult, error) {
+	result := ImportResult{Errors: map[string]error{}}
+
+	srcKeys, err := src.Keys()
+	if err != nil && !errors.Is(err, ErrPartialResults) {
+		return result, err
+	}
+	dstKeys, err := dst.Keys()
+	dstPartial := errors.Is(err, ErrPartialResults)
+	if err != nil && !dstPartial {
+		return result, err
+	}
+
+	existing := make(map[string]bool, len(dstKeys))
+	for _, key := range dstKeys {
+		existing[key] = true
+	}
+
+	for _, key := range srcKeys {
+		if !existing[key] && dstPartial {
+			// the key may be one of the items dst couldn't list
+			_, err := dst.GetMetadata(key)
+			existing[key] = !errors.Is(err, ErrKeyNotFound)
+		}
+
+		if existing[key] {
+			overwrite, err := shouldOverwrite(dst, src, key, opts.OnConflict)
+			if err != nil {
+				result.Errors[key] = err
+				continue
+			}
+			if !overwrite {
+				result.Skipped = append(result.Skipped, key)
+				continue
+			}
+		}
+
+		if !opts.DryRun {
+			item, err := src.Get(key)
+			if err != nil {
+				result.Errors[key] = err
+				continue
+			}
+			if err = dst.Set(item); err != nil {
+				result.Errors[key] = err
+				continue
+			}
+		}
+
+		if existing[key] {
+			result.Overwritten = append(result.Overwritten, key)
+		} else {
+			result.Added = append(result.Added, key)
+		}
+	}
+
+	return result, nil
+}
+
+func shouldOverwrite(dst, src Keyring, key string, policy ConflictPolicy) (bool, error) {
+	switch policy {
+	case ConflictOverwrite:
+		return true, nil
+	case ConflictNewest:
+		srcMeta, err := src.GetMetadata(key)
+		if metadataUnavailable(err) {
+			debugf("No metadata for %q on the source, overwriting: %v", key, err)
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		dstMeta, err := dst.GetMetadata(key)
+		if metadataUnavailable(err) {
+			debugf("No metadata for %q on the destination, overwriting: %v", key, err)
+			return true, nil
+		} else if errors.Is(err, ErrKeyNotFound) {
+			// removed from the destination since it was listed
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		srcTime, dstTime := metadataTime(srcMeta), metadataTime(dstMeta)
+		if srcTime.IsZero() || dstTime.IsZero() {
+			debugf("No modification or creation time for %q, overwriting", key)
+			return true, nil
+		}
+		return srcTime.After(dstTime), nil
+	default:
+		return false, nil
+	}
+}
+
+// metadataTime returns the time ConflictNewest compares: the modification
+// time, or the creation time if there is none.
+func metadataTime(md Metadata) time.Time {
+	if md.ModificationTime.IsZero() {
+		return md.CreationTime
+	}
+	return md.ModificationTime
+}
+
+// metadataUnavailable reports whether err from GetMetadata means the backend
+// can't provide metadata, as opposed to failing to read it.
+func metadataUnavailable(err error) bool {
+	return errors.Is(err, ErrMetadataNotSupported) || errors.Is(err, ErrMetadataNeedsCredentials)
+}
@@ -0,0 +1,200 @@
+package keyring
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// timedKeyring reports fixed modification times as metadata.
+type timedKeyring struct {
+	*ArrayKeyring
+	modified map[string]time.Time
+}
+
+func (k timedKeyring) GetMetadata(key string) (Metadata, error) {
+	return Metadata{ModificationTime: k.modified[key]}, nil
+}
+
+func TestImportConflictPolicies(t *testing.T) {
+	now := time.Now()
+
+	for _, tc := range []struct {
+		policy      ConflictPolicy
+		overwritten []string
+		skipped     []string
+	}{
+		{ConflictSkip, nil, []string{"alpacas", "llamas"}},
+		{ConflictOverwrite, []string{"alpacas", "llamas"}, nil},
+		{ConflictNewest, []string{"llamas"}, []string{"alpacas"}},
+	} {
+		src := timedKeyring{NewArrayKeyring([]Item{
+			{Key: "alpacas", Data: []byte("old alpacas")},
+			{Key: "dromedaries", Data: []byte("new dromedaries")},
+			{Key: "llamas", Data: []byte("new llamas")},
+		}), map[string]time.Time{"alpacas": now.Add(-time.Hour), "llamas": now}}
+
+		dst := timedKeyring{NewArrayKeyring([]Item{
+			{Key: "alpacas", Data: []byte("new alpacas")},
+			{Key: "llamas", Data: []byte("old llamas")},
+		}), map[string]time.Time{"alpacas": now, "llamas": now.Add(-time.Hour)}}
+
+		result, err := Import(dst, src, ImportOptions{OnConflict: tc.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(result.Overwritten)
+		sort.Strings(result.Skipped)
+
+		if !reflect.DeepEqual(result.Added, []string{"dromedaries"}) ||
+			!reflect.DeepEqual(result.Overwritten, tc.overwritten) ||
+			!reflect.DeepEqual(result.Skipped, tc.skipped) ||
+			len(result.Errors) != 0 {
+			t.Fatalf("Policy %d: unexpected result %+v", tc.policy, result)
+		}
+
+		for _, key := range []string{"alpacas", "llamas"} {
+			srcItem, _ := src.Get(key)
+			dstItem, err := dst.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			overwritten := string(dstItem.Data) == string(srcItem.Data)
+			if want := contains(tc.overwritten, key); overwritten != want {
+				t.Fatalf("Policy %d: expected %q overwritten=%v, got %q", tc.policy, key, want, dstItem.Data)
+			}
+		}
+	}
+}
+
+// lockedMetadataKeyring fails to read metadata, as a locked backend would.
+type lockedMetadataKeyring struct {
+	*ArrayKeyring
+}
+
+func (k lockedMetadataKeyring) GetMetadata(key string) (Metadata, error) {
+	return Metadata{}, ErrLocked
+}
+
+func TestImportNewestMetadataErrors(t *testing.T) {
+	src := timedKeyring{
+		NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}}),
+		map[string]time.Time{"llamas": time.Now()},
+	}
+	dst := lockedMetadataKeyring{NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are newer")}})}
+
+	result, err := Import(dst, src, ImportOptions{OnConflict: ConflictNewest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(result.Errors["llamas"], ErrLocked) {
+		t.Fatalf("Expected ErrLocked for llamas, got %v", result.Errors)
+	}
+	if item, _ := dst.Get("llamas"); string(item.Data) != "llamas are newer" {
+		t.Fatalf("Expected the destination to be left alone, got %q", item.Data)
+	}
+
+	// a destination that can't provide metadata is overwritten
+	if overwrite, err := shouldOverwrite(&cliKeyring{}, src, "llamas", ConflictNewest); err != nil || !overwrite {
+		t.Fatalf("Expected an overwrite without metadata, got %v, %v", overwrite, err)
+	}
+}
+
+// partialKeyring lists only some of its keys, like a keychain with items it
+// can't read.
+type partialKeyring struct {
+	timedKeyring
+	listed []string
+}
+
+func (k partialKeyring) Keys() ([]string, error) {
+	return k.listed, ErrPartialResults
+}
+
+func (k partialKeyring) GetMetadata(key string) (Metadata, error) {
+	if _, err := k.Get(key); err != nil {
+		return Metadata{}, err
+	}
+	return k.timedKeyring.GetMetadata(key)
+}
+
+func TestImportPartialResults(t *testing.T) {
+	now := time.Now()
+	src := partialKeyring{timedKeyring{NewArrayKeyring([]Item{
+		{Key: "alpacas", Data: []byte("new alpacas")},
+		{Key: "llamas", Data: []byte("new llamas")},
+	}), map[string]time.Time{}}, []string{"alpacas", "llamas"}}
+	dst := partialKeyring{timedKeyring{NewArrayKeyring([]Item{
+		{Key: "llamas", Data: []byte("old llamas")},
+	}), map[string]time.Time{"llamas": now}}, nil}
+
+	result, err := Import(dst, src, ImportOptions{OnConflict: ConflictSkip})
+	if err != nil {
+		t.Fatalf("Expected partial results to be accepted, got %v", err)
+	}
+	if !reflect.DeepEqual(result.Skipped, []string{"llamas"}) {
+		t.Fatalf("Expected the unlisted llamas on the destination to be skipped, got %+v", result)
+	}
+	if item, _ := dst.Get("llamas"); string(item.Data) != "old llamas" {
+		t.Fatalf("Expected the destination's llamas to be kept, got %q", item.Data)
+	}
+}
+
+func TestImportNewestCreationTime(t *testing.T) {
+	now := time.Now()
+	src := createdKeyring{NewArrayKeyring(nil), map[string]time.Time{"alpacas": now.Add(-time.Hour), "llamas": now}}
+	dst := createdKeyring{NewArrayKeyring(nil), map[string]time.Time{"alpacas": now, "llamas": now.Add(-time.Hour)}}
+
+	for _, key := range []string{"alpacas", "llamas"} {
+		overwrite, err := shouldOverwrite(dst, src, key, ConflictNewest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := key == "llamas"; overwrite != want {
+			t.Fatalf("Expected %q overwrite=%v, got %v", key, want, overwrite)
+		}
+	}
+
+	// without either time the item is overwritten
+	if overwrite, err := shouldOverwrite(dst, src, "dromedaries", ConflictNewest); err != nil || !overwrite {
+		t.Fatalf("Expected an overwrite without times, got %v, %v", overwrite, err)
+	}
+}
+
+// createdKeyring reports only fixed creation times as metadata.
+type createdKeyring struct {
+	*ArrayKeyring
+	created map[string]time.Time
+}
+
+func (k createdKeyring) GetMetadata(key string) (Metadata, error) {
+	return Metadata{CreationTime: k.created[key]}, nil
+}
+
+func TestImportDryRun(t *testing.T) {
+	src := NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}})
+	dst := NewArrayKeyring(nil)
+
+	result, err := Import(dst, src, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Added, []string{"llamas"}) {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	if keys, _ := dst.Keys(); len(keys) != 0 {
+		t.Fatalf("Dry run wrote %v", keys)
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package keyring
+
+import "time"
+
+// Hooks are called around every operation on a Keyring from NewInstrumented.
+// op is one of the operation names reported to an Observer: "get",
+// "get-metadata", "set", "remove" or "keys". key is empty for "keys". Either
+// hook may be nil.
+type Hooks struct {
+	// Before is called before the operation starts
+	Before func(op, key string)
+
+	// After is called once the operation returns, with its error and duration
+	After func(op, key string, err error, dur time.Duration)
+}
+
+// instrumentedKeyring calls Hooks around every operation, see NewInstrumented.
+type instrumentedKeyring struct {
+	Keyring
+	hooks Hooks
+}
+
+// NewInstrumented returns a Keyring that calls hooks around every operation
+// on inner, to collect metrics, start tracing spans or keep an audit log from
+// one place, whichever backend is used. It composes with the other
+// decorators, e.g. wrapping NewTimeout to record timeouts or wrapped by it to
+// record only operations that finished in time.
+//
+// For an audit log of credential access, record the key and outcome of "get"
+// in After, along with the identity of the caller, which only the application
+// knows. Hooks are given keys but never item data, so an audit log doesn't
+// need to be protected like the keyring, although keys may be sensitive too.
+func NewInstrumented(inner Keyring, hooks Hooks) Keyring {
+	return &instrumentedKeyring{inner, hooks}
+}
+
+// instrument calls the hooks around op.
+func (k *instrumentedKeyring) instrument(op, key string, fn func() error) error {
+	if k.hooks.Before != nil {
+		k.hooks.Before(op, key)
+	}
+	start := time.Now()
+	err := fn()
+	if k.hooks.After != nil {
+		k.hooks.After(op, key, err, time.Since(start))
+	}
+	return err
+}
+
+func (k *instrumentedKeyring) Get(key string) (item Item, err error) {
+	err = k.instrument("get", key, func() error {
+		item, err = k.Keyring.Get(key)
+		return err
+	})
+	return item, err
+}
+
+func (k *instrumentedKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return k.instrument("get", key, func() error {
+		return GetWithCallback(k.Keyring, key, fn)
+	})
+}
+
+func (k *instrumentedKeyring) GetMetadata(key string) (md Metadata, err error) {
+	err = k.instrument("get-metadata", key, func() error {
+		md, err = k.Keyring.GetMetadata(key)
+		return err
+	})
+	return md, err
+}
+
+func (k *instrumentedKeyring) Set(item Item) error {
+	return k.instrument("set", item.Key, func() error {
+		return k.Keyring.Set(item)
+	})
+}
+
+func (k *instrumentedKeyring) SetWithOptions(item Item, opts ...SetOption) error {
+	return k.instrument("set", item.Key, func() error {
+		return SetWithOptions(k.Keyring, item, opts...)
+	})
+}
+
+func (k *instrumentedKeyring) SetResult(item Item) (outcome SetOutcome, err error) {
+	err = k.instrument("set", item.Key, func() error {
+		outcome, err = SetResult(k.Keyring, item)
+		return err
+	})
+	return outcome, err
+}
+
+func (k *instrumentedKeyring) Remove(key string) error {
+	return k.instrument("remove", key, func() error {
+		return k.Keyring.Remove(key)
+	})
+}
+
+func (k *instrumentedKeyring) Keys() (keys []string, err error) {
+	err = k.instrument("keys", "", func() error {
+		keys, err = k.Keyring.Keys()
+		return err
+	})
+	return keys, err
+}
+
+func (k *instrumentedKeyring) Unwrap() Keyring {
+	return k.Keyring
+}
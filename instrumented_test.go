@@ -0,0 +1,60 @@
+package keyring
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInstrumentedKeyring(t *testing.T) {
+	var calls []string
+	k := NewInstrumented(NewArrayKeyring(nil), Hooks{
+		Before: func(op, key string) {
+			calls = append(calls, "before "+op+" "+key)
+		},
+		After: func(op, key string, err error, dur time.Duration) {
+			if dur < 0 {
+				t.Fatalf("Unexpected duration %v", dur)
+			}
+			outcome := "ok"
+			if err != nil {
+				outcome = err.Error()
+			}
+			calls = append(calls, "after "+op+" "+key+" "+outcome)
+		},
+	})
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Get("alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if _, err := k.Keys(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"before set llamas",
+		"after set llamas ok",
+		"before get alpacas",
+		"after get alpacas " + ErrKeyNotFound.Error(),
+		"before keys ",
+		"after keys  ok",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestInstrumentedKeyringWithoutHooks(t *testing.T) {
+	k := NewInstrumented(NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}}), Hooks{})
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected data %q", item.Data)
+	}
+}
@@ -6,10 +6,28 @@ package keyring
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"syscall"
 
 	gokeychain "github.com/99designs/go-keychain"
 )
 
+// systemKeychainPath is the keychain of the system domain, shared by all users.
+const systemKeychainPath = "/Library/Keychains/System.keychain"
+
+// errKeychainAttributeMissing is returned when an item can't be read by its
+// service and account because one of them is empty.
+var errKeychainAttributeMissing = errors.New("The keychain item has no service or account to read it by")
+
+// keychainQueryItem runs keychain queries, and is replaced by tests to
+// simulate failures of the Security framework.
+var keychainQueryItem = gokeychain.QueryItem
+
+// keychainCommentKey is kSecAttrComment, which holds Item.Category. go-keychain
+// has no setter for it and doesn't return it from queries.
+const keychainCommentKey = "icmt"
+
 type keychain struct {
 	path    string
 	service string
@@ -19,10 +37,26 @@ type keychain struct {
 	isSynchronizable         bool
 	isAccessibleWhenUnlocked bool
 	isTrusted                bool
+
+	reapplyAccessOnUpdate bool
+	quietUpserts          bool
+	traceQueries          bool
+	requireUniqueLabels   bool
+
+	concurrentPrompts bool
+	promptMu          sync.Mutex
+
+	allowEmptyKeys bool
 }
 
 func init() {
-	supportedBackends[KeychainBackend] = opener(func(cfg Config) (Keyring, error) {
+	supportedBackends[KeychainBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
+		// Items are scoped by service alone, so an empty service would list
+		// and remove items belonging to other applications
+		if cfg.ServiceName == "" {
+			return nil, ErrServiceNameRequired
+		}
+
 		kc := &keychain{
 			service:      cfg.ServiceName,
 			passwordFunc: cfg.KeychainPasswordFunc,
@@ -31,10 +65,34 @@ func init() {
 			// KeychainAccessibleWhenUnlocked is a shorthand for setting the accessibility value.
 			// See: https://developer.apple.com/documentation/security/ksecattraccessiblewhenunlocked
 			isAccessibleWhenUnlocked: cfg.KeychainAccessibleWhenUnlocked,
+
+			reapplyAccessOnUpdate: cfg.KeychainReapplyAccessOnUpdate,
+			quietUpserts:          cfg.KeychainQuietUpserts,
+			traceQueries:          cfg.TraceKeychainQueries,
+			requireUniqueLabels:   cfg.RequireUniqueLabels,
+			concurrentPrompts:     cfg.KeychainConcurrentPrompts,
+			allowEmptyKeys:        cfg.AllowEmptyKeys,
 		}
 		if cfg.KeychainName != "" {
 			kc.path = cfg.KeychainName + ".keychain"
 		}
+
+		switch cfg.KeychainDomain {
+		case "", "user":
+		case "system":
+			if cfg.KeychainName != "" {
+				return nil, fmt.Errorf("KeychainName can't be used with the system keychain domain")
+			}
+			// W_OK, as only root can write to the system keychain
+			if err := syscall.Access(systemKeychainPath, 0x2); err != nil {
+				return nil, fmt.Errorf("%w: %s isn't writable, which requires running as root: %v", ErrKeychainDomainUnavailable, systemKeychainPath, err)
+			}
+			kc.path = systemKeychainPath
+		case "dynamic":
+			return nil, fmt.Errorf("%w: the dynamic domain has no keychain the Security framework can store generic passwords in", ErrKeychainDomainUnavailable)
+		default:
+			return nil, fmt.Errorf("unknown keychain domain %q", cfg.KeychainDomain)
+		}
 		if cfg.KeychainTrustApplication {
 			kc.isTrusted = true
 		}
@@ -43,6 +101,10 @@ func init() {
 }
 
 func (k *keychain) Get(key string) (Item, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
 	query := gokeychain.NewItem()
 	query.SetSecClass(gokeychain.SecClassGenericPassword)
 	query.SetService(k.service)
@@ -57,20 +119,29 @@ func (k *keychain) Get(key string) (Item, error) {
 	}
 
 	debugf("Querying keychain for service=%q, account=%q, keychain=%q", k.service, key, k.path)
-	results, err := gokeychain.QueryItem(query)
-	if err == gokeychain.ErrorItemNotFound || len(results) == 0 {
+	k.traceQuery("QueryItem", query)
+	done := k.serializePrompt()
+	results, err := keychainQueryItem(query)
+	done()
+	if err != nil && !isNoSuchItem(err) {
+		debugf("Error: %#v", err)
+		return Item{}, wrapKeychainError(err)
+	}
+
+	if len(results) == 0 {
 		debugf("No results found")
 		return Item{}, ErrKeyNotFound
 	}
 
+	data, parts, err := unframeData(results[0].Data)
 	if err != nil {
-		debugf("Error: %#v", err)
 		return Item{}, err
 	}
 
 	item := Item{
 		Key:         key,
-		Data:        results[0].Data,
+		Data:        data,
+		DataParts:   parts,
 		Label:       results[0].Label,
 		Description: results[0].Description,
 	}
@@ -79,7 +150,15 @@ func (k *keychain) Get(key string) (Item, error) {
 	return item, nil
 }
 
+func (k *keychain) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
 func (k *keychain) GetMetadata(key string) (Metadata, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Metadata{}, err
+	}
+
 	query := gokeychain.NewItem()
 	query.SetSecClass(gokeychain.SecClassGenericPassword)
 	query.SetService(k.service)
@@ -90,13 +169,14 @@ func (k *keychain) GetMetadata(key string) (Metadata, error) {
 	query.SetReturnRef(true)
 
 	debugf("Querying keychain for metadata of service=%q, account=%q, keychain=%q", k.service, key, k.path)
-	results, err := gokeychain.QueryItem(query)
-	if err == gokeychain.ErrorItemNotFound || len(results) == 0 {
+	k.traceQuery("QueryItem", query)
+	results, err := keychainQueryItem(query)
+	if err != nil && !isNoSuchItem(err) {
+		debugf("Error: %#v", err)
+		return Metadata{}, wrapKeychainError(err)
+	} else if len(results) == 0 {
 		debugf("No results found")
 		return Metadata{}, ErrKeyNotFound
-	} else if err != nil {
-		debugf("Error: %#v", err)
-		return Metadata{}, err
 	}
 
 	md := Metadata{
@@ -106,6 +186,7 @@ func (k *keychain) GetMetadata(key string) (Metadata, error) {
 			Description: results[0].Description,
 		},
 		ModificationTime: results[0].ModificationDate,
+		CreationTime:     results[0].CreationDate,
 	}
 
 	debugf("Found metadata for %q", md.Item.Label)
@@ -113,6 +194,240 @@ func (k *keychain) GetMetadata(key string) (Metadata, error) {
 	return md, nil
 }
 
+// GetByLabel returns the item whose label matches, regardless of its account.
+func (k *keychain) GetByLabel(label string) (Item, error) {
+	results, err := k.queryByLabel(label)
+	if err != nil {
+		return Item{}, err
+	}
+
+	switch len(results) {
+	case 0:
+		return Item{}, ErrKeyNotFound
+	case 1:
+		return k.getByLabelAndAccount(label, results[0].Account)
+	default:
+		return Item{}, ErrMultipleItemsFound
+	}
+}
+
+// GetAllByLabel returns every item whose label matches.
+func (k *keychain) GetAllByLabel(label string) ([]Item, error) {
+	results, err := k.queryByLabel(label)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(results))
+	for _, r := range results {
+		item, err := k.getByLabelAndAccount(label, r.Account)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// RecoverItems queries the attributes of every generic password in the
+// keychain, or in every keychain on the search list when no KeychainName is
+// configured, and fetches the data of the matches one at a time.
+func (k *keychain) RecoverItems(filter func(attrs ItemAttributes) bool) ([]Item, error) {
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetMatchLimit(gokeychain.MatchLimitAll)
+	query.SetReturnAttributes(true)
+
+	if k.path != "" {
+		query.SetMatchSearchList(gokeychain.NewWithPath(k.path))
+	}
+
+	debugf("Querying keychain for all items, keychain=%q", k.path)
+	k.traceQuery("QueryItem", query)
+	results, err := keychainQueryItem(query)
+	if err == gokeychain.ErrorItemNotFound {
+		return []Item{}, nil
+	} else if err != nil {
+		return nil, wrapKeychainError(err)
+	}
+
+	items := []Item{}
+	for _, r := range results {
+		if r.Service == "" || r.Account == "" {
+			debugf("Skipping item without a service or account, label=%q", r.Label)
+			continue
+		}
+
+		attrs := ItemAttributes{
+			Service:          r.Service,
+			Account:          r.Account,
+			Label:            r.Label,
+			Description:      r.Description,
+			CreationTime:     r.CreationDate,
+			ModificationTime: r.ModificationDate,
+		}
+		if !filter(attrs) {
+			continue
+		}
+
+		item, err := k.getByServiceAndAccount(r.Service, r.Account)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// getByServiceAndAccount reads the item with both service and account. Neither
+// may be empty, as go-keychain leaves an empty attribute out of the query,
+// which would then match an item of any service or account.
+func (k *keychain) getByServiceAndAccount(service, account string) (Item, error) {
+	if service == "" || account == "" {
+		return Item{}, errKeychainAttributeMissing
+	}
+
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService(service)
+	query.SetAccount(account)
+	query.SetMatchLimit(gokeychain.MatchLimitOne)
+	query.SetReturnAttributes(true)
+	query.SetReturnData(true)
+
+	if k.path != "" {
+		query.SetMatchSearchList(gokeychain.NewWithPath(k.path))
+	}
+
+	debugf("Querying keychain for service=%q, account=%q, keychain=%q", service, account, k.path)
+	k.traceQuery("QueryItem", query)
+	done := k.serializePrompt()
+	results, err := keychainQueryItem(query)
+	done()
+	if err != nil && !isNoSuchItem(err) {
+		return Item{}, wrapKeychainError(err)
+	} else if len(results) == 0 {
+		return Item{}, ErrKeyNotFound
+	}
+
+	data, parts, err := unframeData(results[0].Data)
+	if err != nil {
+		return Item{}, err
+	}
+
+	return Item{
+		Key:         results[0].Account,
+		Data:        data,
+		DataParts:   parts,
+		Label:       results[0].Label,
+		Description: results[0].Description,
+	}, nil
+}
+
+// queryByLabel returns the attributes of every item with the label. Data can't be
+// returned for more than one item at a time, so it is fetched separately.
+func (k *keychain) queryByLabel(label string) ([]gokeychain.QueryResult, error) {
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService(k.service)
+	query.SetLabel(label)
+	query.SetMatchLimit(gokeychain.MatchLimitAll)
+	query.SetReturnAttributes(true)
+
+	if k.path != "" {
+		query.SetMatchSearchList(gokeychain.NewWithPath(k.path))
+	}
+
+	debugf("Querying keychain for service=%q, label=%q, keychain=%q", k.service, label, k.path)
+	k.traceQuery("QueryItem", query)
+	results, err := keychainQueryItem(query)
+	if err == gokeychain.ErrorItemNotFound {
+		return nil, nil
+	} else if err != nil {
+		debugf("Error: %#v", err)
+		return nil, wrapKeychainError(err)
+	}
+
+	debugf("Found %d results", len(results))
+	return results, nil
+}
+
+// checkUniqueLabel returns ErrDuplicateLabel if requireUniqueLabels is set and
+// an item with another account already has item's label. The attribute query
+// doesn't read data, so it doesn't prompt.
+func (k *keychain) checkUniqueLabel(item Item) error {
+	if !k.requireUniqueLabels || item.Label == "" {
+		return nil
+	}
+
+	results, err := k.queryByLabel(item.Label)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Account != item.Key {
+			return fmt.Errorf("%w: %q is the label of %q", ErrDuplicateLabel, item.Label, r.Account)
+		}
+	}
+	return nil
+}
+
+func (k *keychain) getByLabelAndAccount(label, account string) (Item, error) {
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService(k.service)
+	query.SetLabel(label)
+	query.SetAccount(account)
+	query.SetMatchLimit(gokeychain.MatchLimitOne)
+	query.SetReturnAttributes(true)
+	query.SetReturnData(true)
+
+	if k.path != "" {
+		query.SetMatchSearchList(gokeychain.NewWithPath(k.path))
+	}
+
+	k.traceQuery("QueryItem", query)
+	done := k.serializePrompt()
+	results, err := keychainQueryItem(query)
+	done()
+	if err != nil && !isNoSuchItem(err) {
+		debugf("Error: %#v", err)
+		return Item{}, wrapKeychainError(err)
+	} else if len(results) == 0 {
+		return Item{}, ErrKeyNotFound
+	}
+
+	data, parts, err := unframeData(results[0].Data)
+	if err != nil {
+		return Item{}, err
+	}
+
+	return Item{
+		Key:         results[0].Account,
+		Data:        data,
+		DataParts:   parts,
+		Label:       results[0].Label,
+		Description: results[0].Description,
+	}, nil
+}
+
+// serializePrompt makes queries that return item data, which can show an
+// access prompt, run one at a time so that concurrent reads don't stack up
+// prompts, unless KeychainConcurrentPrompts is set. Attribute-only queries
+// don't prompt and aren't serialized. Call done when the query returns.
+func (k *keychain) serializePrompt() (done func()) {
+	if k.concurrentPrompts {
+		return func() {}
+	}
+	k.promptMu.Lock()
+	return k.promptMu.Unlock
+}
+
+// updateItem updates the data and attributes of an existing item in place. The item keeps
+// the access it was created with, so a change to the trusted applications or accessibility
+// only applies to items created afterwards. Use replaceItem when the new access must apply.
 func (k *keychain) updateItem(kc gokeychain.Keychain, kcItem gokeychain.Item, account string) error {
 	queryItem := gokeychain.NewItem()
 	queryItem.SetSecClass(gokeychain.SecClassGenericPassword)
@@ -125,9 +440,10 @@ func (k *keychain) updateItem(kc gokeychain.Keychain, kcItem gokeychain.Item, ac
 		queryItem.SetMatchSearchList(kc)
 	}
 
-	results, err := gokeychain.QueryItem(queryItem)
+	k.traceQuery("QueryItem", queryItem)
+	results, err := keychainQueryItem(queryItem)
 	if err != nil {
-		return fmt.Errorf("Failed to query keychain: %v", err)
+		return fmt.Errorf("Failed to query keychain: %w", wrapKeychainError(err))
 	}
 	if len(results) == 0 {
 		return errors.New("no results")
@@ -136,22 +452,59 @@ func (k *keychain) updateItem(kc gokeychain.Keychain, kcItem gokeychain.Item, ac
 	// Don't call SetAccess() as this will cause multiple prompts on update, even when we are not updating the AccessList
 	kcItem.SetAccess(nil)
 
+	k.traceQuery("UpdateItem", queryItem, kcItem)
 	if err := gokeychain.UpdateItem(queryItem, kcItem); err != nil {
-		return fmt.Errorf("Failed to update item in keychain: %v", err)
+		return fmt.Errorf("Failed to update item in keychain: %w", wrapKeychainError(err))
 	}
 
 	return nil
 }
 
+// replaceItem deletes the existing item and adds kcItem in its place, so that the item
+// takes on the access settings of kcItem.
+func (k *keychain) replaceItem(kcItem gokeychain.Item, account string) error {
+	if err := k.Remove(account); err != nil && err != ErrKeyNotFound {
+		return fmt.Errorf("Failed to remove item from keychain: %w", err)
+	}
+
+	k.traceQuery("AddItem", kcItem)
+	return wrapKeychainError(gokeychain.AddItem(kcItem))
+}
+
 func (k *keychain) Set(item Item) error {
+	return k.SetWithOptions(item)
+}
+
+func (k *keychain) SetWithOptions(item Item, opts ...SetOption) error {
+	_, err := k.set(item, newSetOptions(opts))
+	return err
+}
+
+// SetResult reports whether AddItem found a duplicate and the item was updated instead.
+func (k *keychain) SetResult(item Item) (SetOutcome, error) {
+	return k.set(item, setOptions{})
+}
+
+func (k *keychain) set(item Item, o setOptions) (SetOutcome, error) {
+	if err := checkKey(item.Key, k.allowEmptyKeys); err != nil {
+		return 0, err
+	}
+	if err := k.checkUniqueLabel(item); err != nil {
+		return 0, err
+	}
+
+	data, err := frameData(item)
+	if err != nil {
+		return 0, err
+	}
+
 	var kc gokeychain.Keychain
 
 	// when we are setting a value, we create or open
 	if k.path != "" {
-		var err error
 		kc, err = k.createOrOpen()
 		if err != nil {
-			return err
+			return 0, wrapKeychainError(err)
 		}
 	}
 
@@ -161,7 +514,8 @@ func (k *keychain) Set(item Item) error {
 	kcItem.SetAccount(item.Key)
 	kcItem.SetLabel(item.Label)
 	kcItem.SetDescription(item.Description)
-	kcItem.SetData(item.Data)
+	kcItem.SetString(keychainCommentKey, item.Category)
+	kcItem.SetData(data)
 
 	if k.path != "" {
 		kcItem.UseKeychain(kc)
@@ -193,25 +547,124 @@ func (k *keychain) Set(item Item) error {
 
 	debugf("Adding service=%q, label=%q, account=%q, trusted=%v to osx keychain %q", k.service, item.Label, item.Key, isTrusted, k.path)
 
-	err := gokeychain.AddItem(kcItem)
+	outcome := SetCreated
+	k.traceQuery("AddItem", kcItem)
+	err = gokeychain.AddItem(kcItem)
 
 	if err == gokeychain.ErrorDuplicateItem {
-		debugf("Item already exists, updating")
-		err = k.updateItem(kc, kcItem, item.Key)
+		if o.createOnly {
+			debugf("Item already exists")
+			return 0, ErrKeyExists
+		}
+		outcome = SetUpdated
+		if k.reapplyAccessOnUpdate || o.reapplyAccess {
+			debugf("Item already exists, replacing")
+			err = k.replaceItem(kcItem, item.Key)
+		} else {
+			if !k.quietUpserts {
+				debugf("Item already exists, updating")
+			}
+			err = k.updateItem(kc, kcItem, item.Key)
+		}
 	}
 
+	if err != nil {
+		return 0, wrapKeychainError(err)
+	}
+
+	return outcome, nil
+}
+
+// Update reads the item, applies mutate and writes the result back with a
+// single UpdateItem, so the item keeps its access settings.
+func (k *keychain) Update(key string, mutate func(*Item) error) error {
+	item, err := k.Get(key)
+	if err != nil {
+		return err
+	}
+	if err = applyUpdate(&item, mutate); err != nil {
+		return err
+	}
+	if err := k.checkUniqueLabel(item); err != nil {
+		return err
+	}
+	data, err := frameData(item)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	kcItem := gokeychain.NewItem()
+	kcItem.SetSecClass(gokeychain.SecClassGenericPassword)
+	kcItem.SetService(k.service)
+	kcItem.SetAccount(item.Key)
+	kcItem.SetLabel(item.Label)
+	kcItem.SetDescription(item.Description)
+	kcItem.SetString(keychainCommentKey, item.Category)
+	kcItem.SetData(data)
+
+	var kc gokeychain.Keychain
+	if k.path != "" {
+		kc = gokeychain.NewWithPath(k.path)
+	}
+
+	debugf("Updating service=%q, label=%q, account=%q in osx keychain %q", k.service, item.Label, item.Key, k.path)
+	return k.updateItem(kc, kcItem, item.Key)
+}
+
+// Touch rewrites the item's account with its current value, which makes the
+// keychain update the item's modification date without touching the data.
+func (k *keychain) Touch(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService(k.service)
+	query.SetAccount(key)
+	query.SetMatchLimit(gokeychain.MatchLimitOne)
+
+	if k.path != "" {
+		kc := gokeychain.NewWithPath(k.path)
+
+		if err := kc.Status(); err != nil {
+			if err == gokeychain.ErrorNoSuchKeychain {
+				return ErrKeyNotFound
+			}
+			return wrapKeychainError(err)
+		}
+
+		query.SetMatchSearchList(kc)
+	}
+
+	update := gokeychain.NewItem()
+	update.SetAccount(key)
+
+	debugf("Touching keychain item service=%q, account=%q, keychain %q", k.service, key, k.path)
+	k.traceQuery("UpdateItem", query, update)
+	err := gokeychain.UpdateItem(query, update)
+	if err == gokeychain.ErrorItemNotFound {
+		return ErrKeyNotFound
+	}
+	return wrapKeychainError(err)
 }
 
 func (k *keychain) Remove(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
+	return k.remove(key, gokeychain.SynchronizableDefault)
+}
+
+// remove deletes the item for key. As in keys, SynchronizableDefault only
+// matches the local item.
+func (k *keychain) remove(key string, sync gokeychain.Synchronizable) error {
 	item := gokeychain.NewItem()
 	item.SetSecClass(gokeychain.SecClassGenericPassword)
 	item.SetService(k.service)
 	item.SetAccount(key)
+	item.SetSynchronizable(sync)
 
 	if k.path != "" {
 		kc := gokeychain.NewWithPath(k.path)
@@ -220,28 +673,253 @@ func (k *keychain) Remove(key string) error {
 			if err == gokeychain.ErrorNoSuchKeychain {
 				return ErrKeyNotFound
 			}
-			return err
+			return wrapKeychainError(err)
 		}
 
 		item.SetMatchSearchList(kc)
 	}
 
 	debugf("Removing keychain item service=%q, account=%q, keychain %q", k.service, key, k.path)
+	k.traceQuery("DeleteItem", item)
 	err := gokeychain.DeleteItem(item)
 	if err == gokeychain.ErrorItemNotFound {
 		return ErrKeyNotFound
 	}
 
-	return err
+	return wrapKeychainError(err)
+}
+
+// Reset deletes every item for the service, like RemoveAll. The keychain
+// itself is left in place, even if it was created by Open, as other services
+// may store items in it.
+func (k *keychain) Reset() error {
+	return k.RemoveAll()
+}
+
+// RemoveAll deletes every item for the service with a single DeleteItem call,
+// which is much faster than removing the accounts one at a time.
+func (k *keychain) RemoveAll() error {
+	if k.service == "" {
+		return errors.New("refusing to remove all keychain items without a service name")
+	}
+
+	item := gokeychain.NewItem()
+	item.SetSecClass(gokeychain.SecClassGenericPassword)
+	item.SetService(k.service)
+
+	if k.path != "" {
+		kc := gokeychain.NewWithPath(k.path)
+
+		if err := kc.Status(); err != nil {
+			if err == gokeychain.ErrorNoSuchKeychain {
+				return nil
+			}
+			return wrapKeychainError(err)
+		}
+
+		item.SetMatchSearchList(kc)
+	}
+
+	debugf("Removing all keychain items for service=%q, keychain %q", k.service, k.path)
+	k.traceQuery("DeleteItem", item)
+	err := gokeychain.DeleteItem(item)
+	if err == gokeychain.ErrorItemNotFound {
+		return nil
+	}
+
+	return wrapKeychainError(err)
+}
+
+func (k *keychain) RemoveMatching(pred func(key string) bool) (int, error) {
+	return removeMatching(k, pred)
 }
 
 func (k *keychain) Keys() ([]string, error) {
+	return k.keys(gokeychain.SynchronizableDefault, "")
+}
+
+func (k *keychain) BackendType() BackendType {
+	return KeychainBackend
+}
+
+// Unlock unlocks the keychain named by Config.KeychainName with the passphrase
+// from KeychainPasswordFunc. The pinned go-keychain can't show the system's
+// unlock prompt, so without a KeychainPasswordFunc, and for the default
+// keychain which the system unlocks at login, it returns ErrNotSupported.
+func (k *keychain) Unlock() error {
+	if k.path == "" || k.passwordFunc == nil {
+		return ErrNotSupported
+	}
+
+	defer k.serializePrompt()()
+
+	passphrase, err := k.passwordFunc("Enter passphrase to unlock keychain")
+	if err != nil {
+		return err
+	}
+
+	debugf("Unlocking keychain %s", k.path)
+	return wrapKeychainError(gokeychain.UnlockAtPath(k.path, passphrase))
+}
+
+// KeysByCategory filters on the kSecAttrComment attribute in the query. Items
+// without a category can't be listed, as an empty attribute is dropped from
+// the query rather than matched, and Get doesn't return the category.
+func (k *keychain) KeysByCategory(category string) ([]string, error) {
+	if category == "" {
+		return nil, fmt.Errorf("%w: the keychain can't list items without a category", ErrNotSupported)
+	}
+	return k.keys(gokeychain.SynchronizableDefault, category)
+}
+
+// KeysSynchronizable returns only the keys of items that are synchronized
+// through iCloud Keychain. See SynchronizableKeyLister.
+func (k *keychain) KeysSynchronizable() ([]string, error) {
+	return k.keys(gokeychain.SynchronizableYes, "")
+}
+
+// KeysWithFlags lists the local and the synchronized items with two attribute
+// queries, so a key stored both ways is listed twice, local first. The pinned
+// go-keychain can't create or read access control, so items never require
+// authentication beyond the keychain's own access prompts, and RequiresAuth
+// is always false.
+func (k *keychain) KeysWithFlags() ([]KeyInfo, error) {
+	local, localErr := k.keys(gokeychain.SynchronizableDefault, "")
+	if localErr != nil && !errors.Is(localErr, ErrPartialResults) {
+		return nil, localErr
+	}
+
+	var synced []string
+	var syncErr error
+	if k.path == "" {
+		synced, syncErr = k.keys(gokeychain.SynchronizableYes, "")
+		if syncErr != nil && !errors.Is(syncErr, ErrPartialResults) {
+			return nil, syncErr
+		}
+	}
+
+	infos := make([]KeyInfo, 0, len(local)+len(synced))
+	for _, key := range local {
+		infos = append(infos, KeyInfo{Key: key})
+	}
+	for _, key := range synced {
+		infos = append(infos, KeyInfo{Key: key, Synchronizable: true})
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	if localErr != nil {
+		return infos, localErr
+	}
+	return infos, syncErr
+}
+
+// DeduplicateSynchronizable lists the synchronizable and the local items
+// separately, with explicit synchronizable constraints, and removes the copy
+// that isn't preferred for every key found in both lists.
+func (k *keychain) DeduplicateSynchronizable(preferSynchronizable bool) (int, error) {
+	synced, err := k.keys(gokeychain.SynchronizableYes, "")
+	if err != nil && !errors.Is(err, ErrPartialResults) {
+		return 0, err
+	}
+	local, err := k.keys(gokeychain.SynchronizableNo, "")
+	if err != nil && !errors.Is(err, ErrPartialResults) {
+		return 0, err
+	}
+
+	var drop gokeychain.Synchronizable = gokeychain.SynchronizableYes
+	if preferSynchronizable {
+		drop = gokeychain.SynchronizableNo
+	}
+
+	isSynced := map[string]bool{}
+	for _, key := range synced {
+		isSynced[key] = true
+	}
+
+	removed := 0
+	errs := RemoveError{}
+	for _, key := range local {
+		if !isSynced[key] {
+			continue
+		}
+		debugf("Removing duplicate of service=%q, account=%q, synchronizable=%v", k.service, key, !preferSynchronizable)
+		if err := k.remove(key, drop); err != nil {
+			errs[key] = err
+			continue
+		}
+		removed++
+	}
+
+	if len(errs) > 0 {
+		return removed, errs
+	}
+	return removed, nil
+}
+
+// Entries lists the service's items with a single attribute-only query, so
+// it doesn't prompt. Items without an account are skipped, as in Keys.
+func (k *keychain) Entries() ([]Metadata, error) {
 	query := gokeychain.NewItem()
 	query.SetSecClass(gokeychain.SecClassGenericPassword)
 	query.SetService(k.service)
 	query.SetMatchLimit(gokeychain.MatchLimitAll)
 	query.SetReturnAttributes(true)
 
+	if k.path != "" {
+		kc := gokeychain.NewWithPath(k.path)
+
+		if err := kc.Status(); err != nil {
+			if err == gokeychain.ErrorNoSuchKeychain {
+				return []Metadata{}, nil
+			}
+			return nil, wrapKeychainError(err)
+		}
+
+		query.SetMatchSearchList(kc)
+	}
+
+	debugf("Querying keychain for metadata of service=%q, keychain=%q", k.service, k.path)
+	k.traceQuery("QueryItem", query)
+	results, err := keychainQueryItem(query)
+	if err == gokeychain.ErrorItemNotFound {
+		return []Metadata{}, nil
+	} else if err != nil {
+		return nil, wrapKeychainError(err)
+	}
+
+	entries := make([]Metadata, 0, len(results))
+	for _, r := range results {
+		if r.Account == "" {
+			continue
+		}
+		entries = append(entries, Metadata{
+			Item: &Item{
+				Key:         r.Account,
+				Label:       r.Label,
+				Description: r.Description,
+			},
+			ModificationTime: r.ModificationDate,
+		})
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].Key < entries[b].Key })
+
+	return entries, nil
+}
+
+// keys lists the accounts of the service's items. SynchronizableDefault leaves
+// kSecAttrSynchronizable out of the query, which only matches local items, and
+// an empty category matches items in any category.
+func (k *keychain) keys(sync gokeychain.Synchronizable, category string) ([]string, error) {
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService(k.service)
+	query.SetMatchLimit(gokeychain.MatchLimitAll)
+	query.SetReturnAttributes(true)
+	query.SetSynchronizable(sync)
+	query.SetString(keychainCommentKey, category)
+
 	if k.path != "" {
 		kc := gokeychain.NewWithPath(k.path)
 
@@ -249,25 +927,91 @@ func (k *keychain) Keys() ([]string, error) {
 			if err == gokeychain.ErrorNoSuchKeychain {
 				return []string{}, nil
 			}
-			return nil, err
+			return nil, wrapKeychainError(err)
 		}
 
 		query.SetMatchSearchList(kc)
 	}
 
 	debugf("Querying keychain for service=%q, keychain=%q", k.service, k.path)
-	results, err := gokeychain.QueryItem(query)
+	k.traceQuery("QueryItem", query)
+	results, err := keychainQueryItem(query)
 	if err != nil {
-		return nil, err
+		return nil, wrapKeychainError(err)
 	}
 
 	debugf("Found %d results", len(results))
-	accountNames := make([]string, len(results))
-	for idx, r := range results {
-		accountNames[idx] = r.Account
+	return accountNames(results)
+}
+
+// accountNames returns the accounts of results, skipping items without one,
+// which other tools have been seen to create. Skipped items are reported
+// with an error wrapping ErrPartialResults alongside the valid accounts.
+func accountNames(results []gokeychain.QueryResult) ([]string, error) {
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.Account == "" {
+			continue
+		}
+		names = append(names, r.Account)
+	}
+
+	if skipped := len(results) - len(names); skipped > 0 {
+		debugf("Skipped %d results without an account", skipped)
+		return names, fmt.Errorf("%w: skipped %d items without an account", ErrPartialResults, skipped)
+	}
+	return names, nil
+}
+
+// AccessGroups returns the distinct access groups of the service's items.
+func (k *keychain) AccessGroups() ([]string, error) {
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService(k.service)
+	query.SetMatchLimit(gokeychain.MatchLimitAll)
+	query.SetReturnAttributes(true)
+
+	if k.path != "" {
+		query.SetMatchSearchList(gokeychain.NewWithPath(k.path))
+	}
+
+	debugf("Querying keychain access groups for service=%q, keychain=%q", k.service, k.path)
+	k.traceQuery("QueryItem", query)
+	results, err := keychainQueryItem(query)
+	if err == gokeychain.ErrorItemNotFound {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, wrapKeychainError(err)
+	}
+
+	seen := map[string]bool{}
+	groups := []string{}
+	for _, r := range results {
+		if r.AccessGroup == "" || seen[r.AccessGroup] {
+			continue
+		}
+		seen[r.AccessGroup] = true
+		groups = append(groups, r.AccessGroup)
 	}
 
-	return accountNames, nil
+	return groups, nil
+}
+
+// isNoSuchItem reports whether err from a query for a single item means that
+// there is no such item, including because the keychain file doesn't exist
+// yet. Other errors, such as a locked keychain or a cancelled prompt, are
+// returned to the caller rather than reported as ErrKeyNotFound.
+func isNoSuchItem(err error) bool {
+	return err == gokeychain.ErrorItemNotFound || err == gokeychain.ErrorNoSuchKeychain
+}
+
+// wrapKeychainError converts errors from the Security framework into a KeychainError
+// so that callers can inspect the OSStatus.
+func wrapKeychainError(err error) error {
+	if status, ok := err.(gokeychain.Error); ok {
+		return &KeychainError{status: int(status), message: status.Error()}
+	}
+	return err
 }
 
 func (k *keychain) createOrOpen() (gokeychain.Keychain, error) {
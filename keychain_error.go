@@ -0,0 +1,48 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrKeychainDomainUnavailable is returned when opening the keychain backend
+// in a Config.KeychainDomain that the current user can't use.
+var ErrKeychainDomainUnavailable = errors.New("The keychain domain is not available to the current user")
+
+// Security framework result codes, see SecBase.h.
+const (
+	errSecItemNotFound          = -25300
+	errSecInteractionNotAllowed = -25308
+)
+
+// KeychainError is returned by the keychain backend when the Security
+// framework reports a failure, giving access to the underlying OSStatus for
+// conditions that have no dedicated error value. Well known statuses still
+// match the package's errors with errors.Is, e.g. ErrKeyNotFound.
+type KeychainError struct {
+	status  int
+	message string
+}
+
+// Status returns the OSStatus code reported by the Security framework.
+func (e *KeychainError) Status() int {
+	return e.status
+}
+
+func (e *KeychainError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("keychain error (%d)", e.status)
+	}
+	return e.message
+}
+
+func (e *KeychainError) Is(target error) bool {
+	switch target {
+	case ErrKeyNotFound:
+		return e.status == errSecItemNotFound
+	case ErrLocked:
+		return e.status == errSecInteractionNotAllowed
+	default:
+		return false
+	}
+}
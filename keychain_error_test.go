@@ -0,0 +1,39 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKeychainErrorStatus(t *testing.T) {
+	err := fmt.Errorf("Failed to update item in keychain: %w", &KeychainError{status: -25293, message: "The user name or passphrase you entered is not correct. (-25293)"})
+
+	var kcErr *KeychainError
+	if !errors.As(err, &kcErr) {
+		t.Fatalf("Expected a KeychainError, got %v", err)
+	}
+	if kcErr.Status() != -25293 {
+		t.Fatalf("Expected status -25293, got %d", kcErr.Status())
+	}
+	if errors.Is(err, ErrKeyNotFound) {
+		t.Fatal("An authentication failure shouldn't match ErrKeyNotFound")
+	}
+}
+
+func TestKeychainErrorMatchesSentinels(t *testing.T) {
+	err := &KeychainError{status: errSecItemNotFound}
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Expected %v to match ErrKeyNotFound", err)
+	}
+}
+
+func TestKeychainErrorLocked(t *testing.T) {
+	err := fmt.Errorf("Failed to query keychain: %w", &KeychainError{status: errSecInteractionNotAllowed})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected %v to match ErrLocked", err)
+	}
+	if errors.Is(err, ErrKeyNotFound) {
+		t.Fatal("A locked keychain shouldn't match ErrKeyNotFound")
+	}
+}
@@ -4,12 +4,16 @@
 package keyring
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
+
+	gokeychain "github.com/99designs/go-keychain"
 )
 
 func TestOSXKeychainKeyringSet(t *testing.T) {
@@ -268,7 +272,557 @@ func TestOSXKeychainRemoveKeyWhenNotEmpty(t *testing.T) {
 	}
 }
 
+func TestOSXKeychainGetByLabel(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	for _, item := range []Item{
+		{Key: "llamas", Label: "Camelids", Data: []byte("llamas are great")},
+		{Key: "alpacas", Label: "Camelids", Data: []byte("alpacas are great")},
+		{Key: "dromedaries", Label: "Dromedaries", Data: []byte("dromedaries are ok")},
+	} {
+		if err := k.Set(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v, err := k.GetByLabel("Dromedaries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Key != "dromedaries" || string(v.Data) != "dromedaries are ok" {
+		t.Fatalf("Unexpected item for label: %#v", v)
+	}
+
+	if _, err = k.GetByLabel("Camelids"); err != ErrMultipleItemsFound {
+		t.Fatalf("expected ErrMultipleItemsFound, got: %v", err)
+	}
+
+	items, err := k.GetAllByLabel("Camelids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+
+	if _, err = k.GetByLabel("Bactrians"); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got: %v", err)
+	}
+}
+
+func TestOSXKeychainReapplyAccessOnUpdate(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are ok")}); err != nil {
+		t.Fatal(err)
+	}
+	created := keychainCreationDate(t, k, "llamas")
+
+	// Keychain dates have a resolution of one second
+	time.Sleep(time.Second)
+
+	// Tighten the access so that the application is no longer trusted
+	k.isTrusted = false
+	k.reapplyAccessOnUpdate = true
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The item was recreated with the new access rather than updated in place
+	if recreated := keychainCreationDate(t, k, "llamas"); !recreated.After(created) {
+		t.Fatalf("Expected the item to be recreated, created %v then %v", created, recreated)
+	}
+}
+
+func TestOSXKeychainSetCreateOnly(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	if err := k.SetWithOptions(Item{Key: "llamas", Data: []byte("llamas are great")}, CreateOnly()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := k.SetWithOptions(Item{Key: "llamas", Data: []byte("llamas are ok")}, CreateOnly())
+	if err != ErrKeyExists {
+		t.Fatalf("expected ErrKeyExists, got: %v", err)
+	}
+}
+
+func TestOSXKeychainAccountNamesSkipsEmpty(t *testing.T) {
+	names, err := accountNames([]gokeychain.QueryResult{
+		{Account: "llamas"},
+		{Account: ""},
+		{Account: "alpacas"},
+	})
+	if !errors.Is(err, ErrPartialResults) {
+		t.Fatalf("expected ErrPartialResults, got: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"llamas", "alpacas"}) {
+		t.Fatalf("Unexpected keys %q", names)
+	}
+}
+
+func TestOSXKeychainTouch(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.Touch("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Touch("alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got: %v", err)
+	}
+}
+
+func TestOSXKeychainKeysSynchronizableWhenNotSynchronized(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := k.KeysSynchronizable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("Expected no synchronized keys, got %q", keys)
+	}
+}
+
+func TestOSXKeychainKeysByCategory(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	for _, item := range []Item{
+		{Key: "aws", Category: "work", Data: []byte("llamas")},
+		{Key: "netflix", Category: "personal", Data: []byte("alpacas")},
+	} {
+		if err := k.Set(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := k.KeysByCategory("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"aws"}) {
+		t.Fatalf("Unexpected keys %q", keys)
+	}
+}
+
+func TestOSXKeychainRequiresServiceName(t *testing.T) {
+	_, err := Open(Config{RequireBackend: KeychainBackend})
+	if !errors.Is(err, ErrServiceNameRequired) {
+		t.Fatalf("Expected ErrServiceNameRequired, got %v", err)
+	}
+}
+
+func TestOSXKeychainDeduplicateSynchronizableWithoutDuplicates(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := DeduplicateSynchronizable(k, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 0 {
+		t.Fatalf("Expected nothing to be removed, got %d", removed)
+	}
+	if _, err := k.Get("llamas"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOSXKeychainConcurrentGets(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := k.Get("llamas"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestOSXKeychainRecoverItems(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	old := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "old-service",
+		isTrusted:    true,
+	}
+	if err := old.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+	items, err := RecoverItems(k, func(attrs ItemAttributes) bool {
+		return attrs.Service == "old-service"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Key != "llamas" || string(items[0].Data) != "llamas are great" {
+		t.Fatalf("Unexpected items %v", items)
+	}
+}
+
+func TestOSXKeychainRecoverItemsWithoutService(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// an item with the same account but no service
+	kcItem := gokeychain.NewItem()
+	kcItem.SetSecClass(gokeychain.SecClassGenericPassword)
+	kcItem.SetAccount("llamas")
+	kcItem.SetData([]byte("not yours"))
+	kcItem.UseKeychain(gokeychain.NewWithPath(path))
+	if err := gokeychain.AddItem(kcItem); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := RecoverItems(k, func(attrs ItemAttributes) bool {
+		return attrs.Account == "llamas"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || string(items[0].Data) != "llamas are great" {
+		t.Fatalf("Unexpected items %v", items)
+	}
+
+	if _, err := k.getByServiceAndAccount("", "llamas"); err != errKeychainAttributeMissing {
+		t.Fatalf("Expected errKeychainAttributeMissing, got %v", err)
+	}
+}
+
+func TestOSXKeychainEntries(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	for _, key := range []string{"llamas", "alpacas"} {
+		if err := k.Set(Item{Key: key, Label: key, Data: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Entries(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "alpacas" || entries[1].Key != "llamas" || entries[1].Label != "llamas" {
+		t.Fatalf("Unexpected entries %v", entries)
+	}
+}
+
+func TestOSXKeychainKeysWithFlags(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	for _, key := range []string{"llamas", "alpacas"} {
+		if err := k.Set(Item{Key: key, Data: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos, err := KeysWithFlags(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0] != (KeyInfo{Key: "alpacas"}) || infos[1] != (KeyInfo{Key: "llamas"}) {
+		t.Fatalf("Unexpected key infos %+v", infos)
+	}
+}
+
+func TestOSXKeychainDomains(t *testing.T) {
+	if _, err := Open(Config{RequireBackend: KeychainBackend, ServiceName: "test", KeychainDomain: "dynamic"}); !errors.Is(err, ErrKeychainDomainUnavailable) {
+		t.Fatalf("Expected ErrKeychainDomainUnavailable, got %v", err)
+	}
+	if _, err := Open(Config{RequireBackend: KeychainBackend, ServiceName: "test", KeychainDomain: "galaxy"}); err == nil {
+		t.Fatal("Expected an error for an unknown domain")
+	}
+
+	_, err := Open(Config{RequireBackend: KeychainBackend, ServiceName: "test", KeychainDomain: "system"})
+	if os.Geteuid() == 0 && err != nil {
+		t.Fatal(err)
+	} else if os.Geteuid() != 0 && !errors.Is(err, ErrKeychainDomainUnavailable) {
+		t.Fatalf("Expected ErrKeychainDomainUnavailable, got %v", err)
+	}
+}
+
+func TestOSXKeychainRequireUniqueLabels(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:                path,
+		passwordFunc:        FixedStringPrompt("test password"),
+		service:             "test",
+		isTrusted:           true,
+		requireUniqueLabels: true,
+	}
+
+	if err := k.Set(Item{Key: "llamas", Label: "Camelids", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	// the item itself may keep its label
+	if err := k.Set(Item{Key: "llamas", Label: "Camelids", Data: []byte("llamas are still great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := k.Set(Item{Key: "alpacas", Label: "Camelids", Data: []byte("alpacas are great")})
+	if !errors.Is(err, ErrDuplicateLabel) {
+		t.Fatalf("Expected ErrDuplicateLabel, got %v", err)
+	}
+	if _, err := k.Get("alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected alpacas not to be stored, got %v", err)
+	}
+}
+
+func TestOSXKeychainUnlock(t *testing.T) {
+	path := tempPath()
+	defer deleteKeychain(t, path)
+
+	k := &keychain{
+		path:         path,
+		passwordFunc: FixedStringPrompt("test password"),
+		service:      "test",
+		isTrusted:    true,
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gokeychain.LockAtPath(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	k.passwordFunc = FixedStringPrompt("wrong password")
+	if err := k.Unlock(); err == nil {
+		t.Fatal("Expected unlocking with the wrong password to fail")
+	}
+
+	k.path = ""
+	if err := k.Unlock(); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported for the default keychain, got %v", err)
+	}
+}
+
+func TestKeychainAttributesOmitData(t *testing.T) {
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService("llamas")
+	query.SetAccount("alpacas")
+	query.SetData([]byte("llamas are great"))
+	query.SetMatchLimit(gokeychain.MatchLimitOne)
+	query.SetReturnData(true)
+
+	attrs := keychainAttributes(query)
+	if attrs[gokeychain.ServiceKey] != `"llamas"` || attrs[gokeychain.AccountKey] != `"alpacas"` {
+		t.Fatalf("Expected the service and account, got %v", attrs)
+	}
+	if attrs[gokeychain.MatchLimitKey] != "MatchLimitOne" || attrs[gokeychain.SecClassKey] != "SecClassGenericPassword" {
+		t.Fatalf("Expected named constants, got %v", attrs)
+	}
+	if attrs[gokeychain.ReturnDataKey] != "true" {
+		t.Fatalf("Expected the return data flag, got %v", attrs)
+	}
+	if attrs[gokeychain.DataKey] != "<16 bytes>" {
+		t.Fatalf("Expected the data to be logged by length only, got %q", attrs[gokeychain.DataKey])
+	}
+}
+
+func keychainCreationDate(t *testing.T, k *keychain, account string) time.Time {
+	t.Helper()
+
+	query := gokeychain.NewItem()
+	query.SetSecClass(gokeychain.SecClassGenericPassword)
+	query.SetService(k.service)
+	query.SetAccount(account)
+	query.SetMatchLimit(gokeychain.MatchLimitOne)
+	query.SetReturnAttributes(true)
+	query.SetMatchSearchList(gokeychain.NewWithPath(k.path))
+
+	results, err := gokeychain.QueryItem(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	return results[0].CreationDate
+}
+
 func tempPath() string {
 	// TODO make filename configurable
 	return filepath.Join(os.TempDir(), fmt.Sprintf("keyring-test-%d.keychain", time.Now().UnixNano()))
 }
+
+func TestOSXKeychainGetFailedQuery(t *testing.T) {
+	defer func(queryItem func(gokeychain.Item) ([]gokeychain.QueryResult, error)) {
+		keychainQueryItem = queryItem
+	}(keychainQueryItem)
+	keychainQueryItem = func(gokeychain.Item) ([]gokeychain.QueryResult, error) {
+		return nil, gokeychain.Error(-25293)
+	}
+
+	k := &keychain{service: "test", isTrusted: true}
+
+	_, err := k.Get("llamas")
+	var kcErr *KeychainError
+	if !errors.As(err, &kcErr) || kcErr.Status() != -25293 {
+		t.Fatalf("Expected a KeychainError with status -25293, got %v", err)
+	}
+	if errors.Is(err, ErrKeyNotFound) {
+		t.Fatal("A failed query shouldn't match ErrKeyNotFound")
+	}
+
+	if _, err := k.GetMetadata("llamas"); !errors.As(err, &kcErr) {
+		t.Fatalf("Expected GetMetadata to return a KeychainError, got %v", err)
+	}
+}
+
+func TestOSXKeychainGetLocked(t *testing.T) {
+	defer func(queryItem func(gokeychain.Item) ([]gokeychain.QueryResult, error)) {
+		keychainQueryItem = queryItem
+	}(keychainQueryItem)
+	keychainQueryItem = func(gokeychain.Item) ([]gokeychain.QueryResult, error) {
+		return nil, gokeychain.ErrorInteractionNotAllowed
+	}
+
+	k := &keychain{service: "test", isTrusted: true}
+
+	_, err := k.Get("llamas")
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected Get to return ErrLocked, got %v", err)
+	}
+	var kcErr *KeychainError
+	if !errors.As(err, &kcErr) || kcErr.Status() != errSecInteractionNotAllowed {
+		t.Fatalf("Expected a KeychainError with status %d, got %v", errSecInteractionNotAllowed, err)
+	}
+
+	if _, err := k.GetMetadata("llamas"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected GetMetadata to return ErrLocked, got %v", err)
+	}
+}
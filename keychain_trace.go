@@ -0,0 +1,127 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package keyring
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	gokeychain "github.com/99designs/go-keychain"
+)
+
+// keychainConstantNames maps the CoreFoundation constants that go-keychain
+// stores for enum attributes back to the names of the go-keychain constants.
+var (
+	keychainConstantNames     map[uintptr]string
+	keychainConstantNamesOnce sync.Once
+)
+
+// keychainAttributes returns the attributes set on a go-keychain item or
+// query, keyed by their Security framework names such as "svce" and "acct".
+// go-keychain doesn't expose them, so they are read from its unexported attr
+// map, and nil is returned if that changes. Data is only reported by length.
+func keychainAttributes(item gokeychain.Item) map[string]string {
+	attrs := reflect.ValueOf(item).FieldByName("attr")
+	if !attrs.IsValid() || attrs.Kind() != reflect.Map {
+		return nil
+	}
+
+	keychainConstantNamesOnce.Do(loadKeychainConstantNames)
+
+	described := map[string]string{}
+	iter := attrs.MapRange()
+	for iter.Next() {
+		value := iter.Value()
+		if value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+
+		switch {
+		case value.Kind() == reflect.String:
+			described[iter.Key().String()] = fmt.Sprintf("%q", value.String())
+		case value.Kind() == reflect.Bool:
+			described[iter.Key().String()] = fmt.Sprint(value.Bool())
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+			described[iter.Key().String()] = fmt.Sprintf("<%d bytes>", value.Len())
+		case value.Kind() == reflect.UnsafePointer || value.Kind() == reflect.Ptr:
+			name, ok := keychainConstantNames[value.Pointer()]
+			if !ok {
+				name = "<" + value.Type().String() + ">"
+			}
+			described[iter.Key().String()] = name
+		default:
+			described[iter.Key().String()] = "<" + value.Type().String() + ">"
+		}
+	}
+	return described
+}
+
+// loadKeychainConstantNames fills keychainConstantNames by setting each enum
+// value on an item and reading back the constant go-keychain stored for it.
+func loadKeychainConstantNames() {
+	keychainConstantNames = map[uintptr]string{}
+	add := func(name, key string, set func(*gokeychain.Item)) {
+		item := gokeychain.NewItem()
+		set(&item)
+		attrs := reflect.ValueOf(item).FieldByName("attr")
+		if !attrs.IsValid() || attrs.Kind() != reflect.Map {
+			return
+		}
+		value := attrs.MapIndex(reflect.ValueOf(key))
+		if value.IsValid() && value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+		if value.IsValid() && (value.Kind() == reflect.UnsafePointer || value.Kind() == reflect.Ptr) {
+			keychainConstantNames[value.Pointer()] = name
+		}
+	}
+
+	add("SecClassGenericPassword", gokeychain.SecClassKey, func(i *gokeychain.Item) { i.SetSecClass(gokeychain.SecClassGenericPassword) })
+	add("SecClassInternetPassword", gokeychain.SecClassKey, func(i *gokeychain.Item) { i.SetSecClass(gokeychain.SecClassInternetPassword) })
+	add("MatchLimitOne", gokeychain.MatchLimitKey, func(i *gokeychain.Item) { i.SetMatchLimit(gokeychain.MatchLimitOne) })
+	add("MatchLimitAll", gokeychain.MatchLimitKey, func(i *gokeychain.Item) { i.SetMatchLimit(gokeychain.MatchLimitAll) })
+	add("SynchronizableAny", gokeychain.SynchronizableKey, func(i *gokeychain.Item) { i.SetSynchronizable(gokeychain.SynchronizableAny) })
+	add("SynchronizableYes", gokeychain.SynchronizableKey, func(i *gokeychain.Item) { i.SetSynchronizable(gokeychain.SynchronizableYes) })
+	add("SynchronizableNo", gokeychain.SynchronizableKey, func(i *gokeychain.Item) { i.SetSynchronizable(gokeychain.SynchronizableNo) })
+	add("AccessibleWhenUnlocked", gokeychain.AccessibleKey, func(i *gokeychain.Item) { i.SetAccessible(gokeychain.AccessibleWhenUnlocked) })
+	add("AccessibleAfterFirstUnlock", gokeychain.AccessibleKey, func(i *gokeychain.Item) { i.SetAccessible(gokeychain.AccessibleAfterFirstUnlock) })
+}
+
+// formatKeychainAttributes formats attributes sorted by key, e.g.
+// `acct="llamas" class=SecClassGenericPassword svce="keyring"`.
+func formatKeychainAttributes(attrs map[string]string) string {
+	if attrs == nil {
+		return "<unavailable>"
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + attrs[key]
+	}
+	return strings.Join(pairs, " ")
+}
+
+// traceQuery logs every attribute of the items passed to a go-keychain call
+// when Config.TraceKeychainQueries is set. For UpdateItem, these are the query
+// followed by the attributes to update.
+func (k *keychain) traceQuery(call string, items ...gokeychain.Item) {
+	if !k.traceQueries {
+		return
+	}
+
+	formatted := make([]string, len(items))
+	for i, item := range items {
+		formatted[i] = "{" + formatKeychainAttributes(keychainAttributes(item)) + "}"
+	}
+	debugf("%s %s", call, strings.Join(formatted, " "))
+}
@@ -0,0 +1,74 @@
+package keyring
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// keychainFileKeyAccount is the keychain account that NewKeychainBackedFile
+// stores the file backend's encryption key under, in the service named by
+// keychainFileKeyService.
+const keychainFileKeyAccount = "file-encryption-key"
+
+// keychainFileKeyService returns the keychain service that holds the
+// encryption key for serviceName's files. It is separate from serviceName so
+// that the key isn't listed by that service's Keys or removed by its
+// RemoveAll or Reset.
+func keychainFileKeyService(serviceName string) string {
+	return serviceName + ".file-encryption-key"
+}
+
+// NewKeychainBackedFile opens a file backend in cfg.FileDir whose passphrase
+// is a random key kept in the keychain, under the service cfg.ServiceName
+// with ".file-encryption-key" appended, instead of one entered by the user.
+// The key is created when the keychain reports that it doesn't have one, and
+// reused afterwards. Any other error reading it, such as ErrLocked, is
+// returned, and the key is only ever created, never overwritten, so that an
+// existing key and the files encrypted with it aren't lost.
+//
+// The files are then only as safe as that keychain item: anyone who can read
+// it, or any process the keychain lets read it without a prompt, can decrypt
+// them, and they can't be decrypted on a machine without it. In return the
+// store can hold more and larger items than the keychain handles well, with
+// no passphrase prompt. cfg.FilePasswordFunc is ignored.
+func NewKeychainBackedFile(cfg Config) (Keyring, error) {
+	kc, err := Open(Config{
+		RequireBackend:                 KeychainBackend,
+		ServiceName:                    keychainFileKeyService(cfg.ServiceName),
+		KeychainName:                   cfg.KeychainName,
+		KeychainDomain:                 cfg.KeychainDomain,
+		KeychainTrustApplication:       cfg.KeychainTrustApplication,
+		KeychainAccessibleWhenUnlocked: cfg.KeychainAccessibleWhenUnlocked,
+		TraceKeychainQueries:           cfg.TraceKeychainQueries,
+		KeychainPasswordFunc:           cfg.KeychainPasswordFunc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := kc.Get(keychainFileKeyAccount)
+	if errors.Is(err, ErrKeyNotFound) {
+		debugf("Creating file encryption key in keychain for service %q", cfg.ServiceName)
+		key := make([]byte, 32)
+		if _, err = rand.Read(key); err != nil {
+			return nil, err
+		}
+		item = Item{
+			Key:         keychainFileKeyAccount,
+			Data:        []byte(base64.StdEncoding.EncodeToString(key)),
+			Label:       cfg.ServiceName + " file encryption key",
+			Description: "Encryption key for the keyring files in " + cfg.FileDir,
+		}
+		// Another process may have created a key since the Get, and
+		// overwriting it would make the files it encrypted unreadable
+		err = SetWithOptions(kc, item, CreateOnly())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.RequireBackend = FileBackend
+	cfg.FilePasswordFunc = FixedStringPrompt(string(item.Data))
+	return Open(cfg)
+}
@@ -0,0 +1,116 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewKeychainBackedFile(t *testing.T) {
+	kc := NewArrayKeyring(nil)
+	var kcCfg Config
+	defer RegisterBackend(KeychainBackend, func(cfg Config) (Keyring, error) {
+		kcCfg = cfg
+		return kc, nil
+	})()
+
+	cfg := Config{
+		ServiceName:    "llamas",
+		KeychainDomain: "user",
+		FileDir:        t.TempDir(),
+		FilePasswordFunc: func(string) (string, error) {
+			t.Fatal("Prompted for a passphrase")
+			return "", nil
+		},
+	}
+
+	k, err := NewKeychainBackedFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := kc.Get(keychainFileKeyAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kcCfg.ServiceName != "llamas.file-encryption-key" || kcCfg.KeychainDomain != "user" {
+		t.Fatalf("Expected the key in its own service of the user domain, got %q in %q", kcCfg.ServiceName, kcCfg.KeychainDomain)
+	}
+
+	// A second open reuses the key and can read the files
+	k, err = NewKeychainBackedFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Value stored was not the value retrieved: %q", item.Data)
+	}
+
+	reused, err := kc.Get(keychainFileKeyAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reused.Data) != string(key.Data) {
+		t.Fatal("Expected the encryption key to be reused")
+	}
+}
+
+// lockedGetKeyring fails every Get, like a locked keychain.
+type lockedGetKeyring struct {
+	*ArrayKeyring
+}
+
+func (k lockedGetKeyring) Get(key string) (Item, error) {
+	return Item{}, ErrLocked
+}
+
+func TestNewKeychainBackedFileLockedKeychain(t *testing.T) {
+	kc := NewArrayKeyring([]Item{{Key: keychainFileKeyAccount, Data: []byte("existing key")}})
+	defer RegisterBackend(KeychainBackend, func(cfg Config) (Keyring, error) {
+		return lockedGetKeyring{kc}, nil
+	})()
+
+	_, err := NewKeychainBackedFile(Config{ServiceName: "llamas", FileDir: t.TempDir()})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected ErrLocked, got %v", err)
+	}
+
+	item, err := kc.Get(keychainFileKeyAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "existing key" {
+		t.Fatal("Expected the existing key to be kept")
+	}
+}
+
+// racingKeyring misses on Get, like a keychain another process is about to
+// write the key to.
+type racingKeyring struct {
+	*ArrayKeyring
+}
+
+func (k racingKeyring) Get(key string) (Item, error) {
+	return Item{}, ErrKeyNotFound
+}
+
+func TestNewKeychainBackedFileKeepsExistingKey(t *testing.T) {
+	kc := NewArrayKeyring([]Item{{Key: keychainFileKeyAccount, Data: []byte("existing key")}})
+	defer RegisterBackend(KeychainBackend, func(cfg Config) (Keyring, error) {
+		return racingKeyring{kc}, nil
+	})()
+
+	_, err := NewKeychainBackedFile(Config{ServiceName: "llamas", FileDir: t.TempDir()})
+	if !errors.Is(err, ErrKeyExists) {
+		t.Fatalf("Expected ErrKeyExists, got %v", err)
+	}
+	if item, _ := kc.Get(keychainFileKeyAccount); string(item.Data) != "existing key" {
+		t.Fatal("Expected the existing key to be kept")
+	}
+}
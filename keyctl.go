@@ -29,6 +29,9 @@ const (
 	KEYCTL_PERM_PROCESS = 24
 )
 
+// The kernel rejects "user" key payloads larger than this, see keyrings(7).
+const keyctlMaxPayloadSize = 32767
+
 // GetPermissions constructs the permission mask from the elements.
 func GetPermissions(process, user, group, others uint32) uint32 {
 	perm := others << KEYCTL_PERM_OTHERS
@@ -50,13 +53,18 @@ func GetKeyringIDForScope(scope string) (int32, error) {
 }
 
 type keyctlKeyring struct {
-	keyring int32
-	perm    uint32
+	keyring        int32
+	perm           uint32
+	allowEmptyKeys bool
+
+	// createdIn is the scope's keyring that the opener created the named keyring
+	// in, or 0 if it already existed
+	createdIn int32
 }
 
 func init() {
-	supportedBackends[KeyCtlBackend] = opener(func(cfg Config) (Keyring, error) {
-		keyring := keyctlKeyring{}
+	supportedBackends[KeyCtlBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
+		keyring := keyctlKeyring{allowEmptyKeys: cfg.AllowEmptyKeys}
 		if cfg.KeyCtlPerm > 0 {
 			keyring.perm = cfg.KeyCtlPerm
 		}
@@ -80,6 +88,7 @@ func init() {
 				if err != nil {
 					return nil, fmt.Errorf("creating named %q keyring failed: %v", cfg.KeyCtlScope, err)
 				}
+				keyring.createdIn = parent
 			}
 			keyring.keyring = namedKeyring
 		}
@@ -89,6 +98,10 @@ func init() {
 }
 
 func (k *keyctlKeyring) Get(name string) (Item, error) {
+	if err := checkKey(name, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
 	key, err := keyctlSearch(k.keyring, "user", name)
 	if err != nil {
 		if errors.Is(err, syscall.ENOKEY) {
@@ -97,19 +110,29 @@ func (k *keyctlKeyring) Get(name string) (Item, error) {
 		return Item{}, err
 	}
 	// data, err := key.Get()
-	data, err := keyctlRead(key)
+	stored, err := keyctlRead(key)
+	if err != nil {
+		return Item{}, err
+	}
+
+	data, parts, err := unframeData(stored)
 	if err != nil {
 		return Item{}, err
 	}
 
 	item := Item{
-		Key:  name,
-		Data: data,
+		Key:       name,
+		Data:      data,
+		DataParts: parts,
 	}
 
 	return item, nil
 }
 
+func (k *keyctlKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
 // GetMetadata for pass returns an error indicating that it's unsupported for this backend.
 // TODO: We can deliver metadata different from the defined ones (e.g. permissions, expire-time, etc).
 func (k *keyctlKeyring) GetMetadata(_ string) (Metadata, error) {
@@ -117,6 +140,20 @@ func (k *keyctlKeyring) GetMetadata(_ string) (Metadata, error) {
 }
 
 func (k *keyctlKeyring) Set(item Item) error {
+	if err := checkKey(item.Key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
+	data, err := frameData(item)
+	if err != nil {
+		return err
+	}
+	item.Data, item.DataParts = data, nil
+
+	if err := checkValueSize(item, k.MaxValueSize()); err != nil {
+		return err
+	}
+
 	if k.perm == 0 {
 		// Keep the default permissions (alswrv-----v------------)
 		_, err := keyctlAdd(k.keyring, "user", item.Key, item.Data)
@@ -149,6 +186,10 @@ func (k *keyctlKeyring) Set(item Item) error {
 }
 
 func (k *keyctlKeyring) Remove(name string) error {
+	if err := checkKey(name, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	key, err := keyctlSearch(k.keyring, "user", name)
 	if err != nil {
 		return ErrKeyNotFound
@@ -182,6 +223,23 @@ func (k *keyctlKeyring) Keys() ([]string, error) {
 	return results, nil
 }
 
+func (k *keyctlKeyring) BackendType() BackendType {
+	return KeyCtlBackend
+}
+
+// MaxValueSize is the largest payload the kernel accepts for a "user" key.
+func (k *keyctlKeyring) MaxValueSize() int {
+	return keyctlMaxPayloadSize
+}
+
+// discardProbe unlinks the named keyring again if opening created it.
+func (k *keyctlKeyring) discardProbe() error {
+	if k.createdIn == 0 {
+		return nil
+	}
+	return keyctlUnlink(k.createdIn, k.keyring)
+}
+
 func (k *keyctlKeyring) createNamedKeyring(parent int32, name string) (int32, error) {
 	if k.perm == 0 {
 		// Keep the default permissions (alswrv-----v------------)
@@ -217,7 +275,7 @@ func getKeyringForScope(scope string) (int32, error) {
 	switch scope {
 	case "user":
 		return int32(unix.KEY_SPEC_USER_KEYRING), nil
-	case "usersession":
+	case "usersession", "user_session":
 		return int32(unix.KEY_SPEC_USER_SESSION_KEYRING), nil
 	case "group":
 		// Not yet implemented in the kernel
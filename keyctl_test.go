@@ -75,7 +75,7 @@ func TestKeyCtlOpenFailWrongScope(t *testing.T) {
 }
 
 func TestKeyCtlOpen(t *testing.T) {
-	scopes := []string{"user", "session", "process", "thread"}
+	scopes := []string{"user", "usersession", "user_session", "session", "process", "thread"}
 	for _, scope := range scopes {
 		_, err := keyring.Open(keyring.Config{
 			AllowedBackends: []keyring.BackendType{keyring.KeyCtlBackend},
@@ -85,6 +85,34 @@ func TestKeyCtlOpen(t *testing.T) {
 	}
 }
 
+func TestKeyCtlScopesAreSeparate(t *testing.T) {
+	session, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.KeyCtlBackend},
+		KeyCtlScope:     "session",
+	})
+	require.NoError(t, err)
+
+	user, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.KeyCtlBackend},
+		KeyCtlScope:     "user",
+	})
+	require.NoError(t, err)
+
+	key := getRandomKeyringName(16)
+	require.NoError(t, session.Set(keyring.Item{Key: key, Data: []byte("loose lips sink ships")}))
+	t.Cleanup(func() { _ = session.Remove(key) })
+
+	_, err = session.Get(key)
+	require.NoError(t, err)
+
+	_, err = user.Get(key)
+	require.ErrorIs(t, err, keyring.ErrKeyNotFound)
+
+	keys, err := user.Keys()
+	require.NoError(t, err)
+	require.NotContains(t, keys, key)
+}
+
 func TestKeyCtlOpenNamed(t *testing.T) {
 	exists, err := doesNamedKeyringExist()
 	require.Falsef(t, exists, "ring %q already exists in scope %q", ringname, ringparent)
@@ -126,6 +154,25 @@ func TestKeyCtlSet(t *testing.T) {
 	require.ErrorIs(t, err, keyring.ErrKeyNotFound)
 }
 
+func TestKeyCtlCandidateBackendsLeavesNoKeyring(t *testing.T) {
+	exists, err := doesNamedKeyringExist()
+	require.Falsef(t, exists, "ring %q already exists in scope %q", ringname, ringparent)
+	require.NoErrorf(t, err, "checking for ring %q in scope %q failed: %v", ringname, ringparent, err)
+	t.Cleanup(cleanupNamedKeyring)
+
+	cfg := keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.KeyCtlBackend},
+		KeyCtlScope:     ringparent,
+		ServiceName:     ringname,
+		KeyCtlPerm:      0x3f3f0000, // "alswrvalswrv------------"
+	}
+	require.Equal(t, []keyring.BackendType{keyring.KeyCtlBackend}, cfg.CandidateBackends())
+
+	exists, err = doesNamedKeyringExist()
+	require.NoError(t, err)
+	require.Falsef(t, exists, "ring %q was left behind in scope %q", ringname, ringparent)
+}
+
 func TestKeyCtlSetNamed(t *testing.T) {
 	exists, err := doesNamedKeyringExist()
 	require.Falsef(t, exists, "ring %q already exists in scope %q", ringname, ringparent)
@@ -251,3 +298,41 @@ func TestKeyCtlListEmptyKeyring(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, keys, 0)
 }
+
+func TestKeyCtlSetTooLarge(t *testing.T) {
+	kr, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.KeyCtlBackend},
+		KeyCtlScope:     "user",
+	})
+	require.NoError(t, err)
+
+	limit := keyring.MaxValueSize(kr)
+	require.Greater(t, limit, 0)
+
+	err = kr.Set(keyring.Item{
+		Key:  "test",
+		Data: make([]byte, limit+1),
+	})
+	require.ErrorIs(t, err, keyring.ErrValueTooLarge)
+}
+
+func TestKeyCtlDataParts(t *testing.T) {
+	kr, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.KeyCtlBackend},
+		KeyCtlScope:     "user",
+		KeyCtlPerm:      0x3f3f0000, // "alswrvalswrv------------"
+	})
+	require.NoError(t, err)
+
+	item := keyring.Item{
+		Key:       "test-parts",
+		Data:      []byte("certificate"),
+		DataParts: map[string][]byte{"key": []byte("private key")},
+	}
+	require.NoError(t, kr.Set(item))
+	t.Cleanup(func() { _ = kr.Remove("test-parts") })
+
+	stored, err := kr.Get("test-parts")
+	require.NoError(t, err)
+	require.Equal(t, item, stored)
+}
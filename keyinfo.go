@@ -0,0 +1,48 @@
+package keyring
+
+import (
+	"errors"
+	"sort"
+)
+
+// KeyInfo is a key along with the flags a credential list shows next to it.
+type KeyInfo struct {
+	Key string
+
+	// RequiresAuth is whether reading the item's data needs the user to
+	// authenticate, e.g. with Touch ID
+	RequiresAuth bool
+
+	// Synchronizable is whether the item follows the user to other devices
+	Synchronizable bool
+}
+
+// KeyInfoLister is implemented by backends that can list keys with their
+// flags without reading item data.
+type KeyInfoLister interface {
+	// Provides a KeyInfo for every item, sorted by key
+	KeysWithFlags() ([]KeyInfo, error)
+}
+
+// KeysWithFlags is a richer Keys for list views, returning each key with
+// whether the item requires authentication to read and whether it is
+// synchronized, sorted by key. Backends that implement KeyInfoLister read the
+// flags without prompting, at the cost of a slightly more expensive query
+// than Keys; for the others, the flags that can't be known are false.
+func KeysWithFlags(kr Keyring) ([]KeyInfo, error) {
+	if l, ok := as[KeyInfoLister](kr); ok {
+		return l.KeysWithFlags()
+	}
+
+	keys, err := kr.Keys()
+	if err != nil && !errors.Is(err, ErrPartialResults) {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	infos := make([]KeyInfo, len(keys))
+	for i, key := range keys {
+		infos[i] = KeyInfo{Key: key}
+	}
+	return infos, err
+}
@@ -0,0 +1,18 @@
+package keyring
+
+import "testing"
+
+func TestKeysWithFlagsFallback(t *testing.T) {
+	k := NewArrayKeyring([]Item{
+		{Key: "llamas", Data: []byte("llamas are great")},
+		{Key: "alpacas", Data: []byte("alpacas are great")},
+	})
+
+	infos, err := KeysWithFlags(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0] != (KeyInfo{Key: "alpacas"}) || infos[1] != (KeyInfo{Key: "llamas"}) {
+		t.Fatalf("Expected unflagged alpacas and llamas, got %+v", infos)
+	}
+}
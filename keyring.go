@@ -3,6 +3,7 @@ package keyring
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"time"
 )
@@ -20,10 +21,15 @@ const (
 	WinCredBackend       BackendType = "wincred"
 	FileBackend          BackendType = "file"
 	PassBackend          BackendType = "pass"
+	CLIBackend           BackendType = "cli"
 )
 
 // This order makes sure the OS-specific backends
-// are picked over the more generic backends.
+// are picked over the more generic backends. It is
+// also the order of preference by security: backends
+// protected by the OS come before the kernel keyring,
+// which doesn't survive a reboot, and the backends
+// encrypted by the library itself come last.
 var backendOrder = []BackendType{
 	// Windows
 	WinCredBackend,
@@ -38,10 +44,20 @@ var backendOrder = []BackendType{
 	FileBackend,
 }
 
-var supportedBackends = map[BackendType]opener{}
+var supportedBackends = map[BackendType]OpenerFunc{}
 
-// AvailableBackends provides a slice of all available backend keys on the current OS.
+// AvailableBackends provides a slice of all available backend keys on the
+// current OS, most preferred first. See AvailableBackendsByPreference.
 func AvailableBackends() []BackendType {
+	return AvailableBackendsByPreference()
+}
+
+// AvailableBackendsByPreference provides a slice of all available backend keys
+// on the current OS in a stable order of preference: the OS credential stores
+// (wincred, keychain, secret-service, kwallet) first, then keyctl, pass and
+// finally the encrypted file backend. Backends added with RegisterBackend come
+// after the built-in ones. Open tries backends in this order by default.
+func AvailableBackendsByPreference() []BackendType {
 	b := []BackendType{}
 	for _, k := range backendOrder {
 		_, ok := supportedBackends[k]
@@ -52,27 +68,130 @@ func AvailableBackends() []BackendType {
 	return b
 }
 
-type opener func(cfg Config) (Keyring, error)
+// OpenerFunc opens a backend for the given Config.
+type OpenerFunc func(cfg Config) (Keyring, error)
+
+// RegisterBackend makes Open use o for backend t, replacing any existing
+// registration, and returns a func that restores the previous one. It is
+// intended for injecting fake backends in tests, and like the built-in
+// registrations it must not be called concurrently with Open.
+func RegisterBackend(t BackendType, o OpenerFunc) (restore func()) {
+	prev, existed := supportedBackends[t]
+	supportedBackends[t] = o
+
+	ordered := false
+	for _, b := range backendOrder {
+		if b == t {
+			ordered = true
+			break
+		}
+	}
+	if !ordered {
+		backendOrder = append(backendOrder, t)
+	}
+
+	return func() {
+		if existed {
+			supportedBackends[t] = prev
+		} else {
+			delete(supportedBackends, t)
+		}
+		if !ordered {
+			for i, b := range backendOrder {
+				if b == t {
+					backendOrder = append(backendOrder[:i], backendOrder[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
 
 // Open will open a specific keyring backend.
 func Open(cfg Config) (Keyring, error) {
+	if cfg.RequireBackend != InvalidBackend {
+		return openRequired(cfg)
+	}
+
 	if cfg.AllowedBackends == nil {
 		cfg.AllowedBackends = AvailableBackends()
 	}
 	debugf("Considering backends: %v", cfg.AllowedBackends)
+
+	candidates := []BackendType{}
 	for _, backend := range cfg.AllowedBackends {
-		if opener, ok := supportedBackends[backend]; ok {
-			openBackend, err := opener(cfg)
-			if err != nil {
-				debugf("Failed backend %s: %s", backend, err)
-				continue
+		if _, ok := supportedBackends[backend]; ok {
+			candidates = append(candidates, backend)
+		}
+	}
+
+	for i, backend := range candidates {
+		openBackend, err := supportedBackends[backend](cfg)
+		if err != nil {
+			debugf("Failed backend %s: %s", backend, err)
+			if cfg.OnFallback != nil {
+				next := InvalidBackend
+				if i+1 < len(candidates) {
+					next = candidates[i+1]
+				}
+				cfg.OnFallback(backend, next, err)
 			}
-			return openBackend, nil
+			continue
 		}
+		return decorate(cfg, backend, openBackend), nil
 	}
 	return nil, ErrNoAvailImpl
 }
 
+// openRequired opens cfg.RequireBackend without falling back to any other backend.
+func openRequired(cfg Config) (Keyring, error) {
+	backend := cfg.RequireBackend
+	open, ok := supportedBackends[backend]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not supported on this platform", ErrBackendNotAvailable, backend)
+	}
+
+	openBackend, err := open(cfg)
+	if err != nil {
+		debugf("Failed backend %s: %s", backend, err)
+		return nil, &backendNotAvailableError{backend: backend, err: err}
+	}
+	return decorate(cfg, backend, openBackend), nil
+}
+
+// backendNotAvailableError matches ErrBackendNotAvailable with errors.Is and
+// unwraps to the reason the required backend failed to open.
+type backendNotAvailableError struct {
+	backend BackendType
+	err     error
+}
+
+func (e *backendNotAvailableError) Error() string {
+	return fmt.Sprintf("%v: %s: %v", ErrBackendNotAvailable, e.backend, e.err)
+}
+
+func (e *backendNotAvailableError) Unwrap() error {
+	return e.err
+}
+
+func (e *backendNotAvailableError) Is(target error) bool {
+	return target == ErrBackendNotAvailable
+}
+
+// decorate wraps an opened backend in the decorators enabled by cfg.
+func decorate(cfg Config, backend BackendType, kr Keyring) Keyring {
+	if cfg.CaseInsensitiveKeys {
+		kr = &caseInsensitiveKeyring{forwarding{kr}}
+	}
+	if cfg.Observer != nil {
+		kr = &observingKeyring{kr, backend, cfg.Observer}
+	}
+	if cfg.VerifyOnWrite {
+		kr = &verifyingKeyring{kr}
+	}
+	return kr
+}
+
 // Item is a thing stored on the keyring.
 type Item struct {
 	Key         string
@@ -80,6 +199,14 @@ type Item struct {
 	Label       string
 	Description string
 
+	// DataParts holds further named secret values stored with Data, e.g. a
+	// private key next to its certificate. Data remains the primary value
+	DataParts map[string][]byte
+
+	// Category groups related items, see KeysByCategory. The keychain can
+	// filter on it but doesn't return it, so it's empty in keychain results
+	Category string
+
 	// Backend specific config
 	KeychainNotTrustApplication bool
 	KeychainNotSynchronizable   bool
@@ -89,10 +216,12 @@ type Item struct {
 // metadata must not require authentication.  The embedded Item should be
 // filled in with an empty Data field.
 // It's allowed for Item to be a nil pointer, indicating that all we
-// have is the timestamps.
+// have is the timestamps. Either timestamp is zero when the backend doesn't
+// record it.
 type Metadata struct {
 	*Item
 	ModificationTime time.Time
+	CreationTime     time.Time
 }
 
 // Keyring provides the uniform interface over the underlying backends.
@@ -109,12 +238,56 @@ type Keyring interface {
 	Keys() ([]string, error)
 }
 
+// Unwrapper is implemented by Keyrings that decorate another Keyring and pass
+// keys and items through unchanged, such as the observer from Config.Observer,
+// so that the helpers can use the optional interfaces of the decorated one.
+// Decorators that change keys or items, such as NewValidating, mustn't
+// implement it, as the helpers would then bypass them.
+type Unwrapper interface {
+	// Returns the decorated Keyring
+	Unwrap() Keyring
+}
+
+// as returns the first Keyring in the decorator chain of kr that implements T.
+func as[T any](kr Keyring) (T, bool) {
+	for kr != nil {
+		if t, ok := kr.(T); ok {
+			return t, true
+		}
+		u, ok := kr.(Unwrapper)
+		if !ok {
+			break
+		}
+		kr = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 // ErrNoAvailImpl is returned by Open when a backend cannot be found.
 var ErrNoAvailImpl = errors.New("Specified keyring backend not available")
 
+// ErrBackendNotAvailable is returned by Open when Config.RequireBackend can't be opened.
+var ErrBackendNotAvailable = errors.New("Required keyring backend not available")
+
+// ErrServiceNameRequired is returned by Open when a backend that scopes items
+// by Config.ServiceName has none.
+var ErrServiceNameRequired = errors.New("A service name is required by the keyring backend")
+
 // ErrKeyNotFound is returned by Keyring Get when the item is not on the keyring.
 var ErrKeyNotFound = errors.New("The specified item could not be found in the keyring")
 
+// ErrLocked is returned when the keychain or collection holding an item is
+// locked and couldn't be unlocked, e.g. because the user dismissed the prompt
+// or user interaction isn't allowed. Callers can ask the user to unlock it
+// and retry.
+//
+// On macOS it is also what Get and GetMetadata return for an item that can't
+// be read until the device has been unlocked once since boot, as the keychain
+// reports the same status for both. A daemon started at boot can treat it as
+// "not ready yet" and retry later rather than failing.
+var ErrLocked = errors.New("The keyring is locked")
+
 // ErrMetadataNeedsCredentials is returned when Metadata is called against a
 // backend which requires credentials even to see metadata.
 var ErrMetadataNeedsCredentials = errors.New("The keyring backend requires credentials for metadata access")
@@ -122,6 +295,13 @@ var ErrMetadataNeedsCredentials = errors.New("The keyring backend requires crede
 // ErrMetadataNotSupported is returned when Metadata is not available for the backend.
 var ErrMetadataNotSupported = errors.New("The keyring backend does not support metadata access")
 
+// ErrPartialResults is returned by Keys alongside the keys it could read when
+// some items had to be skipped. Callers may treat it as a warning.
+var ErrPartialResults = errors.New("Some items on the keyring could not be read")
+
+// ErrNotSupported is returned when an optional operation is not available for the backend.
+var ErrNotSupported = errors.New("The keyring backend does not support this operation")
+
 var (
 	// Debug specifies whether to print debugging output.
 	Debug bool
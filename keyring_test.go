@@ -1,7 +1,11 @@
 package keyring_test
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"reflect"
+	"testing"
 
 	"github.com/99designs/keyring"
 )
@@ -22,3 +26,84 @@ func ExampleOpen() {
 
 	log.Printf("llamas was %v", v)
 }
+
+func TestRegisterBackend(t *testing.T) {
+	const fakeBackend keyring.BackendType = "fake"
+
+	fake := keyring.NewArrayKeyring(nil)
+	restore := keyring.RegisterBackend(fakeBackend, func(cfg keyring.Config) (keyring.Keyring, error) {
+		return fake, nil
+	})
+
+	kr, err := keyring.Open(keyring.Config{AllowedBackends: []keyring.BackendType{fakeBackend}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr != fake {
+		t.Fatalf("Expected the registered backend, got %T", kr)
+	}
+
+	restore()
+
+	for _, b := range keyring.AvailableBackends() {
+		if b == fakeBackend {
+			t.Fatal("Backend still available after restore")
+		}
+	}
+	if _, err = keyring.Open(keyring.Config{AllowedBackends: []keyring.BackendType{fakeBackend}}); err != keyring.ErrNoAvailImpl {
+		t.Fatalf("Expected ErrNoAvailImpl, got %v", err)
+	}
+}
+
+func TestOpenRequireBackend(t *testing.T) {
+	const failingBackend keyring.BackendType = "failing"
+	defer keyring.RegisterBackend(failingBackend, func(cfg keyring.Config) (keyring.Keyring, error) {
+		return nil, errors.New("no llamas")
+	})()
+
+	_, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{failingBackend, keyring.FileBackend},
+		RequireBackend:  failingBackend,
+		FileDir:         t.TempDir(),
+	})
+	if !errors.Is(err, keyring.ErrBackendNotAvailable) {
+		t.Fatalf("Expected ErrBackendNotAvailable, got %v", err)
+	}
+
+	_, err = keyring.Open(keyring.Config{RequireBackend: "unknown"})
+	if !errors.Is(err, keyring.ErrBackendNotAvailable) {
+		t.Fatalf("Expected ErrBackendNotAvailable, got %v", err)
+	}
+}
+
+func TestAvailableBackendsPreferFileLast(t *testing.T) {
+	backends := keyring.AvailableBackendsByPreference()
+	for i, b := range backends {
+		if b == keyring.FileBackend && i != len(backends)-1 {
+			t.Fatalf("Expected the file backend to be least preferred, got %v", backends)
+		}
+	}
+}
+
+func TestOpenOnFallback(t *testing.T) {
+	const failingBackend keyring.BackendType = "failing"
+	defer keyring.RegisterBackend(failingBackend, func(cfg keyring.Config) (keyring.Keyring, error) {
+		return nil, errors.New("no llamas")
+	})()
+
+	var fallbacks []string
+	_, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{failingBackend, keyring.FileBackend},
+		FileDir:         t.TempDir(),
+		OnFallback: func(from, to keyring.BackendType, reason error) {
+			fallbacks = append(fallbacks, fmt.Sprintf("%s -> %s: %v", from, to, reason))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"failing -> file: no llamas"}; !reflect.DeepEqual(fallbacks, expected) {
+		t.Fatalf("Expected fallbacks %q, got %q", expected, fallbacks)
+	}
+}
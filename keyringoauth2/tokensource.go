@@ -0,0 +1,126 @@
+// Package keyringoauth2 persists OAuth2 tokens on a keyring.
+//
+// It is its own module, github.com/99designs/keyring/keyringoauth2, so that
+// only programs that use it depend on golang.org/x/oauth2 and the modules it
+// requires.
+package keyringoauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/99designs/keyring"
+	"golang.org/x/oauth2"
+)
+
+// ErrNoToken is returned when there is no valid token on the keyring and no source to refresh from.
+var ErrNoToken = errors.New("No valid OAuth2 token is stored on the keyring")
+
+// ErrInvalidToken is returned by Load when the item stored under the key isn't a JSON encoded token.
+var ErrInvalidToken = errors.New("The item on the keyring is not an OAuth2 token")
+
+// NewTokenSource returns a TokenSource that returns the token stored under key
+// on k while it is valid. Otherwise it fetches a new token from src and stores
+// it on k before returning it. A stored token that can't be decoded is
+// treated as missing. src may be nil, in which case only the stored token is
+// used.
+//
+// The stored token isn't passed to src, so a src that refreshes tokens must
+// already hold the stored refresh token, e.g. one from Config.TokenSource
+// given the result of Load. NewConfigTokenSource does that itself.
+func NewTokenSource(k keyring.Keyring, key string, src oauth2.TokenSource) oauth2.TokenSource {
+	return &tokenSource{
+		keyring: k,
+		key:     key,
+		src:     func(*oauth2.Token) oauth2.TokenSource { return src },
+	}
+}
+
+// NewConfigTokenSource is like NewTokenSource, but refreshes an expired token
+// with cfg, using the refresh token stored with it under key on k. That keeps
+// a program from having to authorize again after a restart. Without a stored
+// token, or one that can be refreshed, Token returns ErrNoToken or the error
+// from the token endpoint.
+func NewConfigTokenSource(ctx context.Context, k keyring.Keyring, key string, cfg *oauth2.Config) oauth2.TokenSource {
+	return &tokenSource{
+		keyring: k,
+		key:     key,
+		src: func(stored *oauth2.Token) oauth2.TokenSource {
+			if stored == nil || stored.RefreshToken == "" {
+				return nil
+			}
+			return cfg.TokenSource(ctx, stored)
+		},
+	}
+}
+
+type tokenSource struct {
+	mu      sync.Mutex
+	keyring keyring.Keyring
+	key     string
+	// src returns the source of a new token given the stored one, which is
+	// nil if there is none or it can't be decoded. It returns nil when there
+	// is no source.
+	src func(stored *oauth2.Token) oauth2.TokenSource
+}
+
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tok, err := Load(s.keyring, s.key)
+	if errors.Is(err, ErrInvalidToken) {
+		// a new token from src replaces it
+		tok = nil
+	} else if err != nil && !errors.Is(err, keyring.ErrKeyNotFound) {
+		return nil, err
+	}
+	if tok.Valid() {
+		return tok, nil
+	}
+
+	src := s.src(tok)
+	if src == nil {
+		return nil, ErrNoToken
+	}
+	tok, err = src.Token()
+	if err != nil {
+		return nil, err
+	}
+	if err = Store(s.keyring, s.key, tok); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// Load returns the token stored under key on k, keyring.ErrKeyNotFound, or
+// ErrInvalidToken if the stored item isn't a token.
+func Load(k keyring.Keyring, key string) (*oauth2.Token, error) {
+	item, err := k.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var tok oauth2.Token
+	if err = json.Unmarshal(item.Data, &tok); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &tok, nil
+}
+
+// Store saves tok under key on k as JSON.
+func Store(k keyring.Keyring, key string, tok *oauth2.Token) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+
+	return k.Set(keyring.Item{
+		Key:   key,
+		Data:  data,
+		Label: "OAuth2 token",
+	})
+}
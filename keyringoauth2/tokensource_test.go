@@ -0,0 +1,110 @@
+package keyringoauth2_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/keyring"
+	"github.com/99designs/keyring/keyringoauth2"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+type countingSource struct {
+	calls int
+}
+
+func (s *countingSource) Token() (*oauth2.Token, error) {
+	s.calls++
+	return &oauth2.Token{
+		AccessToken: "llamas",
+		Expiry:      time.Now().Add(time.Hour),
+	}, nil
+}
+
+func TestTokenSourceStoresRefreshedToken(t *testing.T) {
+	k := keyring.NewArrayKeyring(nil)
+	src := &countingSource{}
+	ts := keyringoauth2.NewTokenSource(k, "token", src)
+
+	tok, err := ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "llamas", tok.AccessToken)
+
+	_, err = ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, 1, src.calls)
+
+	stored, err := keyringoauth2.Load(k, "token")
+	require.NoError(t, err)
+	require.Equal(t, "llamas", stored.AccessToken)
+}
+
+func TestTokenSourceRefreshesExpiredToken(t *testing.T) {
+	k := keyring.NewArrayKeyring(nil)
+	require.NoError(t, keyringoauth2.Store(k, "token", &oauth2.Token{
+		AccessToken: "alpacas",
+		Expiry:      time.Now().Add(-time.Hour),
+	}))
+
+	src := &countingSource{}
+	tok, err := keyringoauth2.NewTokenSource(k, "token", src).Token()
+	require.NoError(t, err)
+	require.Equal(t, "llamas", tok.AccessToken)
+	require.Equal(t, 1, src.calls)
+}
+
+func TestTokenSourceWithoutSource(t *testing.T) {
+	_, err := keyringoauth2.NewTokenSource(keyring.NewArrayKeyring(nil), "token", nil).Token()
+	require.ErrorIs(t, err, keyringoauth2.ErrNoToken)
+}
+
+func TestTokenSourceReplacesInvalidToken(t *testing.T) {
+	k := keyring.NewArrayKeyring([]keyring.Item{{Key: "token", Data: []byte("not a token")}})
+
+	_, err := keyringoauth2.Load(k, "token")
+	require.ErrorIs(t, err, keyringoauth2.ErrInvalidToken)
+
+	src := &countingSource{}
+	tok, err := keyringoauth2.NewTokenSource(k, "token", src).Token()
+	require.NoError(t, err)
+	require.Equal(t, "llamas", tok.AccessToken)
+
+	stored, err := keyringoauth2.Load(k, "token")
+	require.NoError(t, err)
+	require.Equal(t, "llamas", stored.AccessToken)
+}
+
+func TestConfigTokenSourceUsesStoredRefreshToken(t *testing.T) {
+	var refreshToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		refreshToken = r.PostForm.Get("refresh_token")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"llamas","token_type":"bearer","refresh_token":"vicunas","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	k := keyring.NewArrayKeyring(nil)
+	require.NoError(t, keyringoauth2.Store(k, "token", &oauth2.Token{
+		AccessToken:  "alpacas",
+		RefreshToken: "guanacos",
+		Expiry:       time.Now().Add(-time.Hour),
+	}))
+
+	cfg := &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: server.URL}}
+	tok, err := keyringoauth2.NewConfigTokenSource(context.Background(), k, "token", cfg).Token()
+	require.NoError(t, err)
+	require.Equal(t, "llamas", tok.AccessToken)
+	require.Equal(t, "guanacos", refreshToken)
+
+	stored, err := keyringoauth2.Load(k, "token")
+	require.NoError(t, err)
+	require.Equal(t, "vicunas", stored.RefreshToken)
+
+	_, err = keyringoauth2.NewConfigTokenSource(context.Background(), keyring.NewArrayKeyring(nil), "token", cfg).Token()
+	require.ErrorIs(t, err, keyringoauth2.ErrNoToken)
+}
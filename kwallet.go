@@ -26,7 +26,7 @@ func init() {
 		return
 	}
 
-	supportedBackends[KWalletBackend] = opener(func(cfg Config) (Keyring, error) {
+	supportedBackends[KWalletBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
 		if cfg.ServiceName == "" {
 			cfg.ServiceName = "kdewallet"
 		}
@@ -49,6 +49,8 @@ func init() {
 			name:   cfg.ServiceName,
 			appID:  cfg.KWalletAppID,
 			folder: cfg.KWalletFolder,
+
+			allowEmptyKeys: cfg.AllowEmptyKeys,
 		}
 
 		return ring, ring.openWallet()
@@ -61,6 +63,8 @@ type kwalletKeyring struct {
 	handle int32
 	appID  string
 	folder string
+
+	allowEmptyKeys bool
 }
 
 func (k *kwalletKeyring) openWallet() error {
@@ -81,6 +85,10 @@ func (k *kwalletKeyring) openWallet() error {
 }
 
 func (k *kwalletKeyring) Get(key string) (Item, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
 	err := k.openWallet()
 	if err != nil {
 		return Item{}, err
@@ -103,6 +111,10 @@ func (k *kwalletKeyring) Get(key string) (Item, error) {
 	return item, nil
 }
 
+func (k *kwalletKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
 // GetMetadata for kwallet returns an error indicating that it's unsupported
 // for this backend.
 //
@@ -114,6 +126,10 @@ func (k *kwalletKeyring) GetMetadata(_ string) (Metadata, error) {
 }
 
 func (k *kwalletKeyring) Set(item Item) error {
+	if err := checkKey(item.Key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	err := k.openWallet()
 	if err != nil {
 		return err
@@ -133,6 +149,10 @@ func (k *kwalletKeyring) Set(item Item) error {
 }
 
 func (k *kwalletKeyring) Remove(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	err := k.openWallet()
 	if err != nil {
 		return err
@@ -160,6 +180,10 @@ func (k *kwalletKeyring) Keys() ([]string, error) {
 	return entries, nil
 }
 
+func (k *kwalletKeyring) BackendType() BackendType {
+	return KWalletBackend
+}
+
 func newKwallet() (*kwalletBinding, error) {
 	conn, err := dbus.SessionBus()
 	if err != nil {
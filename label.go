@@ -0,0 +1,59 @@
+package keyring
+
+import "errors"
+
+// LabelGetter is implemented by backends that can look items up by their
+// label rather than their key.
+//
+// Keys map to the account attribute of a keychain item, which is what Get
+// matches on. Items provisioned by other tools often carry a meaningful label
+// but a different or empty account, so they can only be found by label. Labels
+// are not required to be unique, so GetByLabel returns ErrMultipleItemsFound
+// when the label is ambiguous and GetAllByLabel returns every match.
+type LabelGetter interface {
+	// Returns the single Item with a matching label or ErrKeyNotFound
+	GetByLabel(label string) (Item, error)
+	// Returns every Item with a matching label
+	GetAllByLabel(label string) ([]Item, error)
+}
+
+// ErrMultipleItemsFound is returned when a lookup expected a single item but
+// matched several.
+var ErrMultipleItemsFound = errors.New("More than one item in the keyring matched")
+
+// ErrDuplicateLabel is returned by Set when Config.RequireUniqueLabels is set and
+// another item already uses the label.
+var ErrDuplicateLabel = errors.New("The label is already used by another item in the keyring")
+
+// GetLabel returns the label of the item with key, read with GetMetadata so
+// that neither the data is read nor the user prompted. It returns
+// ErrMetadataNotSupported when the backend's metadata doesn't include labels,
+// and the backend's error when it has no metadata without credentials.
+func GetLabel(kr Keyring, key string) (string, error) {
+	item, err := metadataItem(kr, key)
+	if err != nil {
+		return "", err
+	}
+	return item.Label, nil
+}
+
+// GetDescription returns the description of the item with key, like GetLabel.
+func GetDescription(kr Keyring, key string) (string, error) {
+	item, err := metadataItem(kr, key)
+	if err != nil {
+		return "", err
+	}
+	return item.Description, nil
+}
+
+// metadataItem returns the non-secret Item from the metadata of key.
+func metadataItem(kr Keyring, key string) (*Item, error) {
+	md, err := kr.GetMetadata(key)
+	if err != nil {
+		return nil, err
+	}
+	if md.Item == nil {
+		return nil, ErrMetadataNotSupported
+	}
+	return md.Item, nil
+}
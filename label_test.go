@@ -0,0 +1,33 @@
+package keyring
+
+import "testing"
+
+func TestGetLabelAndDescription(t *testing.T) {
+	k := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great"), Label: "Llamas", Description: "Great llamas"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a fresh keyring without a passphrase shows that the data isn't read
+	k = &fileKeyring{dir: k.dir}
+
+	label, err := GetLabel(k, "llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label != "Llamas" {
+		t.Fatalf("Expected label Llamas, got %q", label)
+	}
+
+	description, err := GetDescription(k, "llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if description != "Great llamas" {
+		t.Fatalf("Expected description Great llamas, got %q", description)
+	}
+
+	if _, err := GetLabel(k, "alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
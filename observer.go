@@ -0,0 +1,80 @@
+package keyring
+
+import "time"
+
+// Observer receives the outcome of every operation on a Keyring returned by Open.
+type Observer interface {
+	// Called after op ("get", "get-metadata", "set", "remove" or "keys") completes on backend
+	ObserveOp(op string, backend BackendType, dur time.Duration, err error)
+}
+
+// observingKeyring reports every operation to an Observer, see Config.Observer.
+type observingKeyring struct {
+	Keyring
+	backend  BackendType
+	observer Observer
+}
+
+func (k *observingKeyring) observe(op string, start time.Time, err error) {
+	k.observer.ObserveOp(op, k.backend, time.Since(start), err)
+}
+
+func (k *observingKeyring) Get(key string) (Item, error) {
+	start := time.Now()
+	item, err := k.Keyring.Get(key)
+	k.observe("get", start, err)
+	return item, err
+}
+
+func (k *observingKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	start := time.Now()
+	err := GetWithCallback(k.Keyring, key, fn)
+	k.observe("get", start, err)
+	return err
+}
+
+func (k *observingKeyring) GetMetadata(key string) (Metadata, error) {
+	start := time.Now()
+	md, err := k.Keyring.GetMetadata(key)
+	k.observe("get-metadata", start, err)
+	return md, err
+}
+
+func (k *observingKeyring) Set(item Item) error {
+	start := time.Now()
+	err := k.Keyring.Set(item)
+	k.observe("set", start, err)
+	return err
+}
+
+func (k *observingKeyring) SetWithOptions(item Item, opts ...SetOption) error {
+	start := time.Now()
+	err := SetWithOptions(k.Keyring, item, opts...)
+	k.observe("set", start, err)
+	return err
+}
+
+func (k *observingKeyring) SetResult(item Item) (SetOutcome, error) {
+	start := time.Now()
+	outcome, err := SetResult(k.Keyring, item)
+	k.observe("set", start, err)
+	return outcome, err
+}
+
+func (k *observingKeyring) Remove(key string) error {
+	start := time.Now()
+	err := k.Keyring.Remove(key)
+	k.observe("remove", start, err)
+	return err
+}
+
+func (k *observingKeyring) Keys() ([]string, error) {
+	start := time.Now()
+	keys, err := k.Keyring.Keys()
+	k.observe("keys", start, err)
+	return keys, err
+}
+
+func (k *observingKeyring) Unwrap() Keyring {
+	return k.Keyring
+}
@@ -0,0 +1,51 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+)
+
+type countingObserver struct {
+	calls  map[string]int
+	errors map[string]int
+}
+
+func (o *countingObserver) ObserveOp(op string, backend BackendType, dur time.Duration, err error) {
+	o.calls[string(backend)+" "+op]++
+	if err != nil {
+		o.errors[string(backend)+" "+op]++
+	}
+}
+
+func TestObserverCountsOperations(t *testing.T) {
+	const fakeBackend BackendType = "observed"
+	defer RegisterBackend(fakeBackend, func(cfg Config) (Keyring, error) {
+		return NewArrayKeyring(nil), nil
+	})()
+
+	o := &countingObserver{calls: map[string]int{}, errors: map[string]int{}}
+	k, err := Open(Config{
+		AllowedBackends: []BackendType{fakeBackend},
+		Observer:        o,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = k.Get("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = k.Get("alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	if o.calls["observed set"] != 1 || o.calls["observed get"] != 2 {
+		t.Fatalf("Unexpected call counts %v", o.calls)
+	}
+	if o.errors["observed get"] != 1 {
+		t.Fatalf("Unexpected error counts %v", o.errors)
+	}
+}
@@ -0,0 +1,57 @@
+package keyring
+
+import "errors"
+
+// ErrKeyExists is returned when a create-only write finds the key already on the keyring.
+var ErrKeyExists = errors.New("The specified item already exists in the keyring")
+
+// SetOption changes the behaviour of a single SetWithOptions call.
+type SetOption func(*setOptions)
+
+type setOptions struct {
+	createOnly    bool
+	reapplyAccess bool
+}
+
+func newSetOptions(opts []SetOption) setOptions {
+	var o setOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CreateOnly makes SetWithOptions return ErrKeyExists rather than overwrite an existing item.
+func CreateOnly() SetOption {
+	return func(o *setOptions) {
+		o.createOnly = true
+	}
+}
+
+// ReapplyAccessControl makes the keychain backend replace an existing item so
+// that it takes on the current access settings, as
+// Config.KeychainReapplyAccessOnUpdate does for every write.
+func ReapplyAccessControl() SetOption {
+	return func(o *setOptions) {
+		o.reapplyAccess = true
+	}
+}
+
+// OptionsSetter is implemented by backends that accept SetOptions.
+type OptionsSetter interface {
+	// Stores an Item on the keyring, as modified by opts
+	SetWithOptions(item Item, opts ...SetOption) error
+}
+
+// SetWithOptions stores item on kr as modified by opts. When no options are
+// given it is equivalent to Set; otherwise kr must implement OptionsSetter or
+// ErrNotSupported is returned.
+func SetWithOptions(kr Keyring, item Item, opts ...SetOption) error {
+	if len(opts) == 0 {
+		return kr.Set(item)
+	}
+	if s, ok := as[OptionsSetter](kr); ok {
+		return s.SetWithOptions(item, opts...)
+	}
+	return ErrNotSupported
+}
@@ -0,0 +1,49 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetWithOptionsCreateOnly(t *testing.T) {
+	for name, k := range map[string]Keyring{
+		"array": NewArrayKeyring(nil),
+		"file": &fileKeyring{
+			dir:          t.TempDir(),
+			passwordFunc: FixedStringPrompt("no more secrets"),
+		},
+		"decorated": decorate(Config{
+			CaseInsensitiveKeys: true,
+			VerifyOnWrite:       true,
+			Observer:            &countingObserver{calls: map[string]int{}, errors: map[string]int{}},
+		}, "array", NewInstrumented(NewTimeout(NewArrayKeyring(nil), time.Minute), Hooks{})),
+	} {
+		if err := SetWithOptions(k, Item{Key: "llamas", Data: []byte("llamas are great")}, CreateOnly()); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		err := SetWithOptions(k, Item{Key: "llamas", Data: []byte("llamas are ok")}, CreateOnly())
+		if err != ErrKeyExists {
+			t.Fatalf("%s: Expected ErrKeyExists, got %v", name, err)
+		}
+
+		foundItem, err := k.Get("llamas")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(foundItem.Data) != "llamas are great" {
+			t.Fatalf("%s: Existing item was overwritten: %q", name, foundItem.Data)
+		}
+	}
+}
+
+func TestSetWithOptionsNotSupported(t *testing.T) {
+	k := struct{ Keyring }{NewArrayKeyring(nil)}
+
+	if err := SetWithOptions(k, Item{Key: "llamas"}); err != nil {
+		t.Fatalf("Expected a plain Set without options, got %v", err)
+	}
+	if err := SetWithOptions(k, Item{Key: "alpacas"}, CreateOnly()); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
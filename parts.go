@@ -0,0 +1,43 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// The keychain, wincred and keyctl backends only store Item.Data, so an item
+// with DataParts is framed into a single value for them: dataPartsMagic
+// followed by the JSON encoding of a framedData. Items without parts are
+// stored as their plain Data, as they always were.
+const dataPartsMagic = "keyring-data-parts-v1\x00"
+
+type framedData struct {
+	Data  []byte
+	Parts map[string][]byte
+}
+
+// frameData returns the value to store for item on a backend that only stores Item.Data.
+func frameData(item Item) ([]byte, error) {
+	if len(item.DataParts) == 0 {
+		return item.Data, nil
+	}
+
+	encoded, err := json.Marshal(framedData{Data: item.Data, Parts: item.DataParts})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(dataPartsMagic), encoded...), nil
+}
+
+// unframeData splits a value written by frameData back into Data and DataParts.
+func unframeData(stored []byte) ([]byte, map[string][]byte, error) {
+	if !bytes.HasPrefix(stored, []byte(dataPartsMagic)) {
+		return stored, nil, nil
+	}
+
+	var framed framedData
+	if err := json.Unmarshal(stored[len(dataPartsMagic):], &framed); err != nil {
+		return nil, nil, err
+	}
+	return framed.Data, framed.Parts, nil
+}
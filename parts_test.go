@@ -0,0 +1,50 @@
+package keyring
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFrameData(t *testing.T) {
+	plain := Item{Key: "llamas", Data: []byte("llamas are great")}
+	stored, err := frameData(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, plain.Data) {
+		t.Fatalf("Expected data without parts to be stored as is, got %q", stored)
+	}
+
+	item := Item{
+		Key:       "llamas",
+		Data:      []byte("certificate"),
+		DataParts: map[string][]byte{"key": []byte("private key")},
+	}
+	stored, err = frameData(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, parts, err := unframeData(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "certificate" || string(parts["key"]) != "private key" || len(parts) != 1 {
+		t.Fatalf("Unexpected data %q and parts %q", data, parts)
+	}
+}
+
+func TestFileKeyringDataParts(t *testing.T) {
+	k := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("certificate"), DataParts: map[string][]byte{"key": []byte("private key")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.DataParts["key"]) != "private key" {
+		t.Fatalf("Unexpected parts %q", item.DataParts)
+	}
+}
@@ -4,6 +4,7 @@
 package keyring
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,13 +15,15 @@ import (
 )
 
 func init() {
-	supportedBackends[PassBackend] = opener(func(cfg Config) (Keyring, error) {
+	supportedBackends[PassBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
 		var err error
 
 		pass := &passKeyring{
 			passcmd: cfg.PassCmd,
 			dir:     cfg.PassDir,
 			prefix:  cfg.PassPrefix,
+
+			allowEmptyKeys: cfg.AllowEmptyKeys,
 		}
 
 		if pass.passcmd == "" {
@@ -50,6 +53,12 @@ func init() {
 			return nil, errors.New("The pass program is not available")
 		}
 
+		if cfg.PassInitIfMissing {
+			if err = pass.initIfMissing(cfg.PassGpgRecipients); err != nil {
+				return nil, err
+			}
+		}
+
 		return pass, nil
 	})
 }
@@ -58,6 +67,8 @@ type passKeyring struct {
 	dir     string
 	passcmd string
 	prefix  string
+
+	allowEmptyKeys bool
 }
 
 func (k *passKeyring) pass(args ...string) *exec.Cmd {
@@ -70,7 +81,40 @@ func (k *passKeyring) pass(args ...string) *exec.Cmd {
 	return cmd
 }
 
+// initIfMissing runs `pass init` with recipients unless the store already has
+// a .gpg-id, after checking that gpg has a public key for each recipient.
+// pass itself would accept unknown recipients and only fail on the first Set.
+func (k *passKeyring) initIfMissing(recipients []string) error {
+	if _, err := os.Stat(filepath.Join(k.dir, ".gpg-id")); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if len(recipients) == 0 {
+		return fmt.Errorf("The pass store %s isn't initialized and no PassGpgRecipients are configured", k.dir)
+	}
+	for _, r := range recipients {
+		if out, err := exec.Command("gpg", "--batch", "--list-keys", "--", r).CombinedOutput(); err != nil {
+			return fmt.Errorf("gpg can't find a public key for pass recipient %q: %v: %s", r, err, bytes.TrimSpace(out))
+		}
+	}
+
+	debugf("Initializing pass store %s for %v", k.dir, recipients)
+	var stderr bytes.Buffer
+	cmd := k.pass(append([]string{"init"}, recipients...)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pass init failed: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
 func (k *passKeyring) Get(key string) (Item, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
 	if !k.itemExists(key) {
 		return Item{}, ErrKeyNotFound
 	}
@@ -88,11 +132,35 @@ func (k *passKeyring) Get(key string) (Item, error) {
 	return decoded, err
 }
 
+func (k *passKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
+// GetMetadata for pass returns the modification time of the item's .gpg file.
+// The whole item is encrypted, so like the file backend only the timestamp is
+// available and the returned Metadata has a nil Item.
 func (k *passKeyring) GetMetadata(key string) (Metadata, error) {
-	return Metadata{}, nil
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Metadata{}, err
+	}
+
+	stat, err := os.Stat(k.itemPath(key))
+	if os.IsNotExist(err) {
+		return Metadata{}, ErrKeyNotFound
+	} else if err != nil {
+		return Metadata{}, err
+	}
+
+	return Metadata{
+		ModificationTime: stat.ModTime(),
+	}, nil
 }
 
 func (k *passKeyring) Set(i Item) error {
+	if err := checkKey(i.Key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	bytes, err := json.Marshal(i)
 	if err != nil {
 		return err
@@ -110,7 +178,18 @@ func (k *passKeyring) Set(i Item) error {
 	return nil
 }
 
+func (k *passKeyring) Touch(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+	return touchFile(k.itemPath(key))
+}
+
 func (k *passKeyring) Remove(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	if !k.itemExists(key) {
 		return ErrKeyNotFound
 	}
@@ -125,9 +204,12 @@ func (k *passKeyring) Remove(key string) error {
 	return nil
 }
 
+func (k *passKeyring) itemPath(key string) string {
+	return filepath.Join(k.dir, k.prefix, key+".gpg")
+}
+
 func (k *passKeyring) itemExists(key string) bool {
-	var path = filepath.Join(k.dir, k.prefix, key+".gpg")
-	_, err := os.Stat(path)
+	_, err := os.Stat(k.itemPath(key))
 
 	return err == nil
 }
@@ -164,3 +246,7 @@ func (k *passKeyring) Keys() ([]string, error) {
 
 	return keys, err
 }
+
+func (k *passKeyring) BackendType() BackendType {
+	return PassBackend
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func runCmd(t *testing.T, cmds ...string) {
@@ -248,3 +249,81 @@ func TestPassKeyringKeysWithSymlink(t *testing.T) {
 		t.Fatalf("Expected keys %v, got %v", expectedKeys, keys)
 	}
 }
+
+func TestPassKeyringGetMetadata(t *testing.T) {
+	k, teardown := setup(t)
+	defer teardown(t)
+
+	if _, err := k.GetMetadata("llamas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	md, err := k.GetMetadata("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.ModificationTime.Before(before) {
+		t.Fatalf("Unexpected modification time %v", md.ModificationTime)
+	}
+}
+
+// writeScript writes an executable shell script named name to dir.
+func writeScript(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPassInitIfMissing(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "log")
+	passcmd := writeScript(t, bin, "pass", `echo "$@" >> `+log+`
+if [ "$1" = init ]; then
+	mkdir -p "$PASSWORD_STORE_DIR"
+	shift
+	echo "$@" > "$PASSWORD_STORE_DIR/.gpg-id"
+fi
+`)
+	writeScript(t, bin, "gpg", `for last; do :; done
+[ "$last" = test@example.com ] || { echo "gpg: error reading key: No public key" >&2; exit 2; }
+`)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := Config{
+		RequireBackend:    PassBackend,
+		PassCmd:           passcmd,
+		PassDir:           filepath.Join(t.TempDir(), "store"),
+		PassInitIfMissing: true,
+		PassGpgRecipients: []string{"test@example.com"},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := Open(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(calls) != "init test@example.com\n" {
+		t.Fatalf("Expected a single pass init, got %q", calls)
+	}
+
+	cfg.PassDir = filepath.Join(t.TempDir(), "store")
+	cfg.PassGpgRecipients = []string{"nobody@example.com"}
+	if _, err := Open(cfg); err == nil {
+		t.Fatal("Expected an error for a recipient without a public key")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.PassDir, ".gpg-id")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the store not to be initialized, got %v", err)
+	}
+}
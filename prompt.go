@@ -1,12 +1,17 @@
 package keyring
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 
 	"golang.org/x/term"
 )
 
+// ErrPromptCanceled is returned by a ContextPrompt whose context is done before the prompt returns.
+var ErrPromptCanceled = errors.New("The password prompt was canceled")
+
 // PromptFunc is a function used to prompt the user for a password.
 type PromptFunc func(string) (string, error)
 
@@ -25,3 +30,33 @@ func FixedStringPrompt(value string) PromptFunc {
 		return value, nil
 	}
 }
+
+// ContextPrompt returns a PromptFunc that runs prompt but returns
+// ErrPromptCanceled as soon as ctx is done, e.g. for use as
+// Config.FilePasswordFunc in an application that may shut down while waiting
+// for a passphrase.
+//
+// A terminal read can't be interrupted, so after a cancellation the goroutine
+// running prompt lingers until it returns, i.e. until the next newline on the
+// terminal, and its result is discarded.
+func ContextPrompt(ctx context.Context, prompt PromptFunc) PromptFunc {
+	return func(msg string) (string, error) {
+		type result struct {
+			value string
+			err   error
+		}
+
+		done := make(chan result, 1)
+		go func() {
+			value, err := prompt(msg)
+			done <- result{value, err}
+		}()
+
+		select {
+		case r := <-done:
+			return r.value, r.err
+		case <-ctx.Done():
+			return "", ErrPromptCanceled
+		}
+	}
+}
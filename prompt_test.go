@@ -0,0 +1,33 @@
+package keyring
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextPromptCanceled(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	blocking := func(string) (string, error) {
+		<-unblock
+		return "too late", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ContextPrompt(ctx, blocking)("Enter passphrase"); err != ErrPromptCanceled {
+		t.Fatalf("Expected ErrPromptCanceled, got %v", err)
+	}
+}
+
+func TestContextPromptAnswered(t *testing.T) {
+	v, err := ContextPrompt(context.Background(), FixedStringPrompt("llamas"))("Enter passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "llamas" {
+		t.Fatalf("Unexpected value %q", v)
+	}
+}
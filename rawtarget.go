@@ -0,0 +1,36 @@
+package keyring
+
+// RawTargetReader is implemented by the wincred backend to read credentials
+// by their Windows target name, outside of the keyring's prefix and service.
+type RawTargetReader interface {
+	// Returns the generic credential with the exact target name, or ErrKeyNotFound
+	GetRawTarget(target string) (Item, error)
+
+	// Returns the target names of every credential of the current user
+	ListAllTargets() ([]string, error)
+}
+
+// GetRawTarget reads the generic credential with the given Windows target
+// name, e.g. one created by `cmdkey /generic:` or another application. Unlike
+// Get, the target isn't prefixed with WinCredPrefix and the service name, so
+// this bypasses the keyring's namespacing and is meant for interoperating
+// with credentials the keyring didn't write. The item's Key is the target and
+// its Description the credential's comment. It returns ErrNotSupported on
+// backends other than wincred.
+func GetRawTarget(kr Keyring, target string) (Item, error) {
+	if r, ok := as[RawTargetReader](kr); ok {
+		return r.GetRawTarget(target)
+	}
+	return Item{}, ErrNotSupported
+}
+
+// ListAllTargets returns the Windows target names of all of the user's
+// credentials, whichever application created them, sorted. Like
+// GetRawTarget it bypasses the keyring's namespacing, and returns
+// ErrNotSupported on backends other than wincred.
+func ListAllTargets(kr Keyring) ([]string, error) {
+	if r, ok := as[RawTargetReader](kr); ok {
+		return r.ListAllTargets()
+	}
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,13 @@
+package keyring
+
+import "testing"
+
+func TestRawTargetNotSupported(t *testing.T) {
+	k := NewArrayKeyring(nil)
+	if _, err := GetRawTarget(k, "llamas"); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+	if _, err := ListAllTargets(k); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
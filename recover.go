@@ -0,0 +1,36 @@
+package keyring
+
+import "time"
+
+// ItemAttributes are the attributes of a stored item that RecoverItems
+// filters on, read without the item's data.
+type ItemAttributes struct {
+	Service          string
+	Account          string
+	Label            string
+	Description      string
+	CreationTime     time.Time
+	ModificationTime time.Time
+}
+
+// ItemRecoverer is implemented by backends that can find items stored under
+// any service, not just the configured one.
+type ItemRecoverer interface {
+	// Returns every item, under any service, whose attributes satisfy filter
+	RecoverItems(filter func(attrs ItemAttributes) bool) ([]Item, error)
+}
+
+// RecoverItems is an administrative tool for finding items stored under a
+// different service, e.g. after a change or typo in Config.ServiceName, so
+// that they can be Set again on the right keyring. It lists the attributes of
+// every item the process can see, whichever application created it, and reads
+// the data of those that satisfy filter, which may prompt for each of them.
+// The returned items are keyed by their account. Keychain items without a
+// service or account are left out, as they can't be read unambiguously. It
+// returns ErrNotSupported if the backend doesn't implement ItemRecoverer.
+func RecoverItems(kr Keyring, filter func(attrs ItemAttributes) bool) ([]Item, error) {
+	if r, ok := as[ItemRecoverer](kr); ok {
+		return r.RecoverItems(filter)
+	}
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,10 @@
+package keyring
+
+import "testing"
+
+func TestRecoverItemsNotSupported(t *testing.T) {
+	_, err := RecoverItems(NewArrayKeyring(nil), func(ItemAttributes) bool { return true })
+	if err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
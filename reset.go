@@ -0,0 +1,20 @@
+package keyring
+
+// Resetter is implemented by backends that can return their storage to the
+// state it was in before the first item was written.
+type Resetter interface {
+	// Deletes every item and the backend's storage for the configured
+	// service. This is destructive and can't be undone
+	Reset() error
+}
+
+// Reset deletes every item on the keyring along with any storage the backend
+// created for them, leaving a clean slate for tests or a factory reset. It is
+// destructive and can't be undone. It returns ErrNotSupported if the backend
+// doesn't implement Resetter; use RemoveAll to just delete the items.
+func Reset(kr Keyring) error {
+	if r, ok := as[Resetter](kr); ok {
+		return r.Reset()
+	}
+	return ErrNotSupported
+}
@@ -0,0 +1,40 @@
+package keyring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResetFileKeyring(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keyring")
+	k := &fileKeyring{
+		dir:          dir,
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Reset(k); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("Expected %s to be removed, got %v", dir, err)
+	}
+	if k.password != "" {
+		t.Fatal("Expected the passphrase to be forgotten")
+	}
+
+	if err := Reset(k); err != nil {
+		t.Fatalf("Expected resetting a missing directory to succeed, got %v", err)
+	}
+}
+
+func TestResetNotSupported(t *testing.T) {
+	// hide the ArrayKeyring's methods that aren't part of Keyring
+	k := struct{ Keyring }{NewArrayKeyring(nil)}
+	if err := Reset(k); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
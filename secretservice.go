@@ -7,8 +7,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/godbus/dbus"
 	"github.com/gsterjov/go-libsecret"
@@ -21,7 +22,7 @@ func init() {
 		return
 	}
 
-	supportedBackends[SecretServiceBackend] = opener(func(cfg Config) (Keyring, error) {
+	supportedBackends[SecretServiceBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
 		if cfg.ServiceName == "" {
 			cfg.ServiceName = "secret-service"
 		}
@@ -35,8 +36,11 @@ func init() {
 		}
 
 		ring := &secretsKeyring{
-			name:    cfg.LibSecretCollectionName,
-			service: service,
+			name:        cfg.LibSecretCollectionName,
+			serviceName: cfg.ServiceName,
+			service:     service,
+
+			allowEmptyKeys: cfg.AllowEmptyKeys,
 		}
 
 		return ring, ring.openSecrets()
@@ -44,10 +48,13 @@ func init() {
 }
 
 type secretsKeyring struct {
-	name       string
-	service    *libsecret.Service
-	collection *libsecret.Collection
-	session    *libsecret.Session
+	name        string
+	serviceName string
+	service     *libsecret.Service
+	collection  *libsecret.Collection
+	session     *libsecret.Session
+
+	allowEmptyKeys bool
 }
 
 var errCollectionNotFound = errors.New("The collection does not exist. Please add a key first")
@@ -116,6 +123,10 @@ func (k *secretsKeyring) openCollection() error {
 }
 
 func (k *secretsKeyring) Get(key string) (Item, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
 	if err := k.openCollection(); err != nil {
 		if err == errCollectionNotFound {
 			return Item{}, ErrKeyNotFound
@@ -123,7 +134,7 @@ func (k *secretsKeyring) Get(key string) (Item, error) {
 		return Item{}, err
 	}
 
-	items, err := k.collection.SearchItems(key)
+	items, err := k.findItems(key)
 	if err != nil {
 		return Item{}, err
 	}
@@ -136,20 +147,13 @@ func (k *secretsKeyring) Get(key string) (Item, error) {
 	// with the same profile name
 	item := items[0]
 
-	locked, err := item.Locked()
-	if err != nil {
+	if err := k.ensureItemUnlocked(item); err != nil {
 		return Item{}, err
 	}
 
-	if locked {
-		if err := k.service.Unlock(item); err != nil {
-			return Item{}, err
-		}
-	}
-
 	secret, err := item.GetSecret(k.session)
 	if err != nil {
-		return Item{}, err
+		return Item{}, secretServiceError(err)
 	}
 
 	// pack the secret into the item
@@ -161,19 +165,66 @@ func (k *secretsKeyring) Get(key string) (Item, error) {
 	return ret, err
 }
 
-// GetMetadata for libsecret returns an error indicating that it's unsupported
-// for this backend.
-//
-// libsecret actually implements a metadata system which we could use, "Secret
-// Attributes"; I found no indication in documentation of anything like an
-// automatically maintained last-modification timestamp, so to use this we'd
-// need to have a SetMetadata API too.  Which we're not yet doing, but feel
-// free to contribute patches.
+func (k *secretsKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
+// GetMetadata for libsecret returns the modification time the secret service
+// maintains for the item in its "Modified" property, which can be read without
+// unlocking the item. The rest of the item is stored in the encrypted secret,
+// so the returned Metadata has a nil Item.
 func (k *secretsKeyring) GetMetadata(key string) (Metadata, error) {
-	return Metadata{}, ErrMetadataNeedsCredentials
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Metadata{}, err
+	}
+
+	if err := k.openCollection(); err != nil {
+		if err == errCollectionNotFound {
+			return Metadata{}, ErrKeyNotFound
+		}
+		return Metadata{}, err
+	}
+
+	items, err := k.findItems(key)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if len(items) == 0 {
+		return Metadata{}, ErrKeyNotFound
+	}
+
+	modified, err := k.itemProperty(items[0], "Modified")
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	seconds, ok := modified.Value().(uint64)
+	if !ok {
+		return Metadata{}, fmt.Errorf("unexpected type %s for the Modified property", modified.Signature())
+	}
+
+	return Metadata{
+		ModificationTime: time.Unix(int64(seconds), 0),
+	}, nil
+}
+
+// itemProperty reads a property of the org.freedesktop.Secret.Item interface
+// that go-libsecret doesn't expose.
+func (k *secretsKeyring) itemProperty(item libsecret.Item, name string) (dbus.Variant, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+
+	return conn.Object(libsecret.DBusServiceName, item.Path()).GetProperty("org.freedesktop.Secret.Item." + name)
 }
 
 func (k *secretsKeyring) Set(item Item) error {
+	if err := checkKey(item.Key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	err := k.openSecrets()
 	if err != nil {
 		return err
@@ -201,14 +252,130 @@ func (k *secretsKeyring) Set(item Item) error {
 
 	secret := libsecret.NewSecret(k.session, []byte{}, data, "application/json")
 
-	if _, err := k.collection.CreateItem(item.Key, secret, true); err != nil {
+	return k.createItem(item.Key, secret)
+}
+
+// createItem stores secret in the collection like Collection.CreateItem, but
+// also sets "service" and "account" attributes so that other tools can find
+// it, e.g. with `secret-tool lookup service <ServiceName> account <key>`.
+func (k *secretsKeyring) createItem(key string, secret *libsecret.Secret) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+
+	properties := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label": dbus.MakeVariant(key),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(map[string]string{
+			"profile": key,
+			"service": k.serviceName,
+			"account": key,
+		}),
+	}
+
+	var path, prompt dbus.ObjectPath
+	err = conn.Object(libsecret.DBusServiceName, k.collection.Path()).
+		Call("org.freedesktop.Secret.Collection.CreateItem", 0, properties, secret, true).
+		Store(&path, &prompt)
+	if err != nil {
+		return err
+	}
+
+	if prompt != "/" {
+		result, err := libsecret.NewPrompt(conn, prompt).Prompt()
+		if err != nil {
+			return err
+		}
+		path = result.Value().(dbus.ObjectPath)
+	}
+
+	// Items written before the service and account attributes were added
+	// aren't replaced, as their attributes differ, so remove them to keep
+	// Get from finding the stale copy. Items of other services sharing the
+	// collection are left alone.
+	items, err := k.findItems(key)
+	if err != nil {
 		return err
 	}
+	for _, item := range items {
+		if item.Path() == path {
+			continue
+		}
+		if err := item.Delete(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// findItems returns the items in the collection with key as their profile
+// that belong to this keyring's service: those with its service attribute,
+// and those with none, as written before the attribute was added.
+func (k *secretsKeyring) findItems(key string) ([]libsecret.Item, error) {
+	items, err := k.collection.SearchItems(key)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := []libsecret.Item{}
+	for _, item := range items {
+		service, ok, err := k.serviceAttribute(item)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || service == k.serviceName {
+			owned = append(owned, item)
+		}
+	}
+	return owned, nil
+}
+
+// serviceAttribute returns the item's "service" attribute, and whether it
+// has one.
+func (k *secretsKeyring) serviceAttribute(item libsecret.Item) (string, bool, error) {
+	attributes, err := k.itemProperty(item, "Attributes")
+	if err != nil {
+		return "", false, err
+	}
+
+	values, ok := attributes.Value().(map[string]string)
+	if !ok {
+		return "", false, fmt.Errorf("unexpected type %s for the Attributes property", attributes.Signature())
+	}
+
+	service, ok := values["service"]
+	return service, ok, nil
+}
+
+// searchItems returns the items in the collection whose attributes include
+// all of attributes.
+func (k *secretsKeyring) searchItems(attributes map[string]string) ([]libsecret.Item, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []dbus.ObjectPath
+	err = conn.Object(libsecret.DBusServiceName, k.collection.Path()).
+		Call("org.freedesktop.Secret.Collection.SearchItems", 0, attributes).
+		Store(&paths)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []libsecret.Item{}
+	for _, path := range paths {
+		items = append(items, *libsecret.NewItem(conn, path))
+	}
+	return items, nil
+}
+
 func (k *secretsKeyring) Remove(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	if err := k.openCollection(); err != nil {
 		if err == errCollectionNotFound {
 			return ErrKeyNotFound
@@ -216,7 +383,7 @@ func (k *secretsKeyring) Remove(key string) error {
 		return err
 	}
 
-	items, err := k.collection.SearchItems(key)
+	items, err := k.findItems(key)
 	if err != nil {
 		return err
 	}
@@ -230,19 +397,12 @@ func (k *secretsKeyring) Remove(key string) error {
 	// so just get the first item found
 	item := items[0]
 
-	locked, err := item.Locked()
-	if err != nil {
+	if err := k.ensureItemUnlocked(item); err != nil {
 		return err
 	}
 
-	if locked {
-		if err := k.service.Unlock(item); err != nil {
-			return err
-		}
-	}
-
 	if err := item.Delete(); err != nil {
-		return err
+		return secretServiceError(err)
 	}
 
 	return nil
@@ -258,10 +418,23 @@ func (k *secretsKeyring) Keys() ([]string, error) {
 	if err := k.ensureCollectionUnlocked(); err != nil {
 		return nil, err
 	}
-	items, err := k.collection.Items()
+	items, err := k.searchItems(map[string]string{"service": k.serviceName})
+	if err != nil {
+		return nil, err
+	}
+
+	// Items written before the service attribute was added can only be
+	// found by listing the whole collection
+	all, err := k.collection.Items()
 	if err != nil {
 		return nil, err
 	}
+	for _, item := range all {
+		if _, ok, err := k.serviceAttribute(item); err == nil && !ok {
+			items = append(items, item)
+		}
+	}
+
 	keys := []string{}
 	for _, item := range items {
 		label, err := item.Label() // FIXME: err is being silently ignored
@@ -272,6 +445,22 @@ func (k *secretsKeyring) Keys() ([]string, error) {
 	return keys, nil
 }
 
+func (k *secretsKeyring) BackendType() BackendType {
+	return SecretServiceBackend
+}
+
+// Unlock unlocks the collection, which may show the Secret Service's prompt.
+// There's nothing to unlock until the collection is created by the first Set.
+func (k *secretsKeyring) Unlock() error {
+	if err := k.openCollection(); err != nil {
+		if err == errCollectionNotFound {
+			return nil
+		}
+		return err
+	}
+	return k.ensureCollectionUnlocked()
+}
+
 // deleteCollection deletes the keyring's collection if it exists. This is mainly to support testing.
 func (k *secretsKeyring) deleteCollection() error {
 	if err := k.openCollection(); err != nil {
@@ -282,12 +471,52 @@ func (k *secretsKeyring) deleteCollection() error {
 
 // unlock the collection if it's locked
 func (k *secretsKeyring) ensureCollectionUnlocked() error {
-	locked, err := k.collection.Locked()
+	return k.ensureUnlocked(k.collection, k.collection.Locked)
+}
+
+// unlock the item if it's locked
+func (k *secretsKeyring) ensureItemUnlocked(item libsecret.Item) error {
+	return k.ensureUnlocked(item, item.Locked)
+}
+
+// ensureUnlocked unlocks object if it's locked. The unlock prompt reports
+// success even when the user dismisses it, so the state is checked again
+// afterwards and ErrLocked returned if the object is still locked.
+func (k *secretsKeyring) ensureUnlocked(object libsecret.DBusObject, isLocked func() (bool, error)) error {
+	locked, err := isLocked()
 	if err != nil {
 		return err
 	}
 	if !locked {
 		return nil
 	}
-	return k.service.Unlock(k.collection)
+
+	if err = k.service.Unlock(object); err != nil {
+		return secretServiceError(err)
+	}
+
+	if locked, err = isLocked(); err != nil {
+		return err
+	} else if locked {
+		return ErrLocked
+	}
+	return nil
+}
+
+// secretServiceError translates the Secret Service's D-Bus errors into the
+// package's errors where there is one.
+func secretServiceError(err error) error {
+	var name string
+	var dbusErr dbus.Error
+	var dbusErrPtr *dbus.Error
+	if errors.As(err, &dbusErr) {
+		name = dbusErr.Name
+	} else if errors.As(err, &dbusErrPtr) {
+		name = dbusErrPtr.Name
+	}
+
+	if name == "org.freedesktop.Secret.Error.IsLocked" {
+		return ErrLocked
+	}
+	return err
 }
@@ -8,6 +8,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/godbus/dbus"
 	"github.com/gsterjov/go-libsecret"
 )
 
@@ -33,8 +34,9 @@ func libSecretSetup(t *testing.T) (Keyring, func(t *testing.T)) {
 		t.Fatal(err)
 	}
 	kr := &secretsKeyring{
-		name:    "keyring-test",
-		service: service,
+		name:        "keyring-test",
+		serviceName: "keyring-test",
+		service:     service,
 	}
 	return kr, func(t *testing.T) {
 		t.Helper()
@@ -145,6 +147,59 @@ func TestLibSecretRemoveWhenNotEmpty(t *testing.T) {
 	}
 }
 
+func TestLibSecretServiceAttributes(t *testing.T) {
+	kr, teardown := libSecretSetup(t)
+	defer teardown(t)
+
+	if err := kr.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var unlocked, locked []dbus.ObjectPath
+	attributes := map[string]string{"service": "keyring-test", "account": "llamas"}
+	err = conn.Object(libsecret.DBusServiceName, libsecret.DBusPath).
+		Call("org.freedesktop.Secret.Service.SearchItems", 0, attributes).
+		Store(&unlocked, &locked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unlocked)+len(locked) != 1 {
+		t.Fatalf("Expected 1 item with service and account attributes, got %d", len(unlocked)+len(locked))
+	}
+}
+
+func TestLibSecretSetReplacesItem(t *testing.T) {
+	kr, teardown := libSecretSetup(t)
+	defer teardown(t)
+
+	for _, data := range []string{"llamas are ok", "llamas are great"} {
+		if err := kr.Set(Item{Key: "llamas", Data: []byte(data)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, err := kr.(*secretsKeyring).collection.SearchItems("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item after setting the key twice, got %d", len(items))
+	}
+
+	item, err := kr.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Expected the second value, got %q", item.Data)
+	}
+}
+
 func TestLibSpecialCharacters(t *testing.T) {
 	decoded := decodeKeyringString("keyring_2dtest")
 	if decoded != "keyring-test" {
@@ -166,3 +221,73 @@ func TestLibSpecialCharacters(t *testing.T) {
 		t.Fatal("incorrect decodeKeyringString")
 	}
 }
+
+func TestLibSecretUnlock(t *testing.T) {
+	kr, teardown := libSecretSetup(t)
+
+	// there's no collection to unlock yet
+	if err := Unlock(kr); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := kr.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	defer teardown(t)
+
+	if err := Unlock(kr); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecretServiceLockedError(t *testing.T) {
+	locked := dbus.Error{Name: "org.freedesktop.Secret.Error.IsLocked"}
+	if err := secretServiceError(locked); err != ErrLocked {
+		t.Fatalf("Expected ErrLocked, got %v", err)
+	}
+	if err := secretServiceError(&locked); err != ErrLocked {
+		t.Fatalf("Expected ErrLocked, got %v", err)
+	}
+
+	other := dbus.Error{Name: "org.freedesktop.Secret.Error.NoSuchObject"}
+	if err := secretServiceError(other); err == ErrLocked {
+		t.Fatal("Expected other errors to be passed through")
+	}
+}
+
+func TestLibSecretSharedCollection(t *testing.T) {
+	kr, teardown := libSecretSetup(t)
+	defer teardown(t)
+
+	other := &secretsKeyring{
+		name:        "keyring-test",
+		serviceName: "keyring-test-other",
+		service:     kr.(*secretsKeyring).service,
+	}
+
+	if err := other.Set(Item{Key: "llamas", Data: []byte("other llamas")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Set(Item{Key: "alpacas", Data: []byte("other alpacas")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := other.Get("llamas")
+	if err != nil {
+		t.Fatalf("Expected the other service's item to be kept, got %v", err)
+	}
+	if string(item.Data) != "other llamas" {
+		t.Fatalf("Expected the other service's value, got %q", item.Data)
+	}
+
+	keys, err := kr.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "llamas" {
+		t.Fatalf("Expected only this service's keys, got %v", keys)
+	}
+}
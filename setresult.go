@@ -0,0 +1,39 @@
+package keyring
+
+// SetOutcome reports whether SetResult created or updated an item.
+type SetOutcome int
+
+const (
+	// SetCreated means the key wasn't on the keyring before the write
+	SetCreated SetOutcome = iota + 1
+	// SetUpdated means the write replaced an existing item
+	SetUpdated
+)
+
+func (o SetOutcome) String() string {
+	switch o {
+	case SetCreated:
+		return "created"
+	case SetUpdated:
+		return "updated"
+	}
+	return "unknown"
+}
+
+// SetResulter is implemented by backends that can tell whether a write
+// created or updated an item without a separate lookup.
+type SetResulter interface {
+	// Stores an Item on the keyring and reports whether it already existed
+	SetResult(item Item) (SetOutcome, error)
+}
+
+// SetResult stores item on kr like Set and reports whether it created a new
+// item or updated an existing one, e.g. to tell "credential added" from
+// "credential rotated". kr must implement SetResulter or ErrNotSupported is
+// returned.
+func SetResult(kr Keyring, item Item) (SetOutcome, error) {
+	if s, ok := as[SetResulter](kr); ok {
+		return s.SetResult(item)
+	}
+	return 0, ErrNotSupported
+}
@@ -0,0 +1,34 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetResult(t *testing.T) {
+	for name, k := range map[string]Keyring{
+		"array": NewArrayKeyring(nil),
+		"file":  &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")},
+		"decorated": decorate(Config{
+			CaseInsensitiveKeys: true,
+			VerifyOnWrite:       true,
+			Observer:            &countingObserver{calls: map[string]int{}, errors: map[string]int{}},
+		}, "array", NewInstrumented(NewTimeout(NewArrayKeyring(nil), time.Minute), Hooks{})),
+	} {
+		outcome, err := SetResult(k, Item{Key: "llamas", Data: []byte("llamas are ok")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outcome != SetCreated {
+			t.Fatalf("%s: Expected the first write to create the item, got %v", name, outcome)
+		}
+
+		outcome, err = SetResult(k, Item{Key: "llamas", Data: []byte("llamas are great")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outcome != SetUpdated {
+			t.Fatalf("%s: Expected the second write to update the item, got %v", name, outcome)
+		}
+	}
+}
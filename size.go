@@ -0,0 +1,33 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValueSizeLimiter is implemented by backends that bound the size of the
+// secret data they can store.
+type ValueSizeLimiter interface {
+	// Returns the maximum length of Item.Data in bytes, or 0 when unbounded or unknown
+	MaxValueSize() int
+}
+
+// ErrValueTooLarge is returned by Set when Item.Data exceeds the backend's maximum value size.
+var ErrValueTooLarge = errors.New("The item data is too large for the keyring backend")
+
+// MaxValueSize returns the maximum length of Item.Data that kr can store, or 0
+// when the limit is unbounded or unknown.
+func MaxValueSize(kr Keyring) int {
+	if l, ok := as[ValueSizeLimiter](kr); ok {
+		return l.MaxValueSize()
+	}
+	return 0
+}
+
+// checkValueSize returns ErrValueTooLarge when the item's data exceeds limit.
+func checkValueSize(item Item, limit int) error {
+	if limit > 0 && len(item.Data) > limit {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrValueTooLarge, len(item.Data), limit)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckValueSize(t *testing.T) {
+	item := Item{Key: "llamas", Data: []byte("llamas are great")}
+
+	if err := checkValueSize(item, 0); err != nil {
+		t.Fatalf("Expected no limit, got %v", err)
+	}
+	if err := checkValueSize(item, len(item.Data)); err != nil {
+		t.Fatalf("Expected data at the limit to be accepted, got %v", err)
+	}
+	if err := checkValueSize(item, 4); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge, got %v", err)
+	}
+}
+
+func TestMaxValueSizeWhenUnbounded(t *testing.T) {
+	if l := MaxValueSize(NewArrayKeyring(nil)); l != 0 {
+		t.Fatalf("Expected no limit for the array keyring, got %d", l)
+	}
+}
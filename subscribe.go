@@ -0,0 +1,155 @@
+package keyring
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WatchOp is the kind of change a WatchEvent reports.
+type WatchOp int
+
+const (
+	// WatchCreated means the key was added to the keyring
+	WatchCreated WatchOp = iota + 1
+	// WatchModified means the item's modification time changed
+	WatchModified
+	// WatchRemoved means the key was removed from the keyring
+	WatchRemoved
+)
+
+func (o WatchOp) String() string {
+	switch o {
+	case WatchCreated:
+		return "created"
+	case WatchModified:
+		return "modified"
+	case WatchRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// WatchEvent is a change to a key reported by SubscribeAll.
+type WatchEvent struct {
+	Key string
+	Op  WatchOp
+	// The item's modification time, zero for removals and backends without one
+	ModificationTime time.Time
+}
+
+// minSubscribeInterval bounds how often SubscribeAll polls. It isn't
+// configurable; tests lower it.
+var minSubscribeInterval = time.Second
+
+// SubscribeAll reports changes to any key on kr. It polls Keys and
+// GetMetadata every interval, which is raised to a second if shorter, and
+// sends an event for every key created, modified or removed since the
+// previous poll. The one second minimum is fixed, so that polling can't
+// keep a backend busy. Modifications are detected by modification time, so they
+// aren't reported for backends without metadata, or more precisely than the
+// backend records times. Neither reads item data nor prompts.
+//
+// Polls that fail are skipped. A key whose metadata can't be read, e.g.
+// because the keyring is locked, keeps the time it had, and is only reported
+// as created once its metadata can be read. The returned func stops polling and closes the
+// channel; events that the caller doesn't receive hold up the next poll.
+// kr must be safe to use concurrently with the caller, as polling happens on
+// another goroutine.
+func SubscribeAll(kr Keyring, interval time.Duration) (<-chan WatchEvent, func(), error) {
+	if interval < minSubscribeInterval {
+		interval = minSubscribeInterval
+	}
+
+	prev, err := modificationTimes(kr, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan WatchEvent)
+	stop := make(chan struct{})
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { close(stop) })
+	}
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			next, err := modificationTimes(kr, prev)
+			if err != nil {
+				debugf("Skipping poll for changes: %v", err)
+				continue
+			}
+
+			for _, event := range diffModificationTimes(prev, next) {
+				select {
+				case events <- event:
+				case <-stop:
+					return
+				}
+			}
+			prev = next
+		}
+	}()
+
+	return events, cancel, nil
+}
+
+// modificationTimes returns the modification time of every key on kr, zero
+// when the backend has no metadata. Keys whose metadata can't be read keep
+// their time in prev, and are left out if they have none.
+func modificationTimes(kr Keyring, prev map[string]time.Time) (map[string]time.Time, error) {
+	keys, err := kr.Keys()
+	if err != nil && !errors.Is(err, ErrPartialResults) {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time, len(keys))
+	for _, key := range keys {
+		md, err := kr.GetMetadata(key)
+		if errors.Is(err, ErrKeyNotFound) {
+			// removed since Keys
+			continue
+		} else if err != nil && !metadataUnavailable(err) {
+			debugf("Keeping the previous time of %q: %v", key, err)
+			if t, ok := prev[key]; ok {
+				times[key] = t
+			}
+			continue
+		}
+		times[key] = md.ModificationTime
+	}
+	return times, nil
+}
+
+// diffModificationTimes returns the events that turn prev into next, ordered by key.
+func diffModificationTimes(prev, next map[string]time.Time) []WatchEvent {
+	events := []WatchEvent{}
+	for key, t := range next {
+		if before, ok := prev[key]; !ok {
+			events = append(events, WatchEvent{Key: key, Op: WatchCreated, ModificationTime: t})
+		} else if !t.Equal(before) {
+			events = append(events, WatchEvent{Key: key, Op: WatchModified, ModificationTime: t})
+		}
+	}
+	for key := range prev {
+		if _, ok := next[key]; !ok {
+			events = append(events, WatchEvent{Key: key, Op: WatchRemoved})
+		}
+	}
+
+	sort.Slice(events, func(a, b int) bool { return events[a].Key < events[b].Key })
+	return events
+}
@@ -0,0 +1,80 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffModificationTimes(t *testing.T) {
+	now := time.Now()
+	prev := map[string]time.Time{"alpacas": now, "llamas": now, "vicunas": now}
+	next := map[string]time.Time{"alpacas": now, "guanacos": now, "llamas": now.Add(time.Second)}
+
+	events := diffModificationTimes(prev, next)
+	expected := []WatchEvent{
+		{Key: "guanacos", Op: WatchCreated, ModificationTime: now},
+		{Key: "llamas", Op: WatchModified, ModificationTime: now.Add(time.Second)},
+		{Key: "vicunas", Op: WatchRemoved},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i].Key != expected[i].Key || events[i].Op != expected[i].Op || !events[i].ModificationTime.Equal(expected[i].ModificationTime) {
+			t.Fatalf("Expected %v, got %v", expected, events)
+		}
+	}
+}
+
+func TestModificationTimesKeepsTimesOnErrors(t *testing.T) {
+	now := time.Now()
+	k := lockedMetadataKeyring{NewArrayKeyring([]Item{
+		{Key: "alpacas", Data: []byte("alpacas are ok")},
+		{Key: "llamas", Data: []byte("llamas are great")},
+	})}
+
+	times, err := modificationTimes(k, map[string]time.Time{"llamas": now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 1 || !times["llamas"].Equal(now) {
+		t.Fatalf("Expected only llamas with its previous time, got %v", times)
+	}
+
+	// backends without metadata report zero times
+	times, err = modificationTimes(NewArrayKeyring([]Item{{Key: "llamas"}}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, ok := times["llamas"]; !ok || !md.IsZero() {
+		t.Fatalf("Expected a zero time for llamas, got %v", times)
+	}
+}
+
+func TestSubscribeAll(t *testing.T) {
+	defer func(d time.Duration) { minSubscribeInterval = d }(minSubscribeInterval)
+	minSubscribeInterval = time.Millisecond
+
+	k := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+	events, cancel, err := SubscribeAll(k, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Key != "llamas" || event.Op != WatchCreated {
+			t.Fatalf("Unexpected event %v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,19 @@
+package keyring
+
+// Syncer is implemented by backends that can defer flushing writes to disk.
+type Syncer interface {
+	// Flushes every item written so far to disk
+	Sync() error
+}
+
+// Sync flushes the items written to kr to disk, so that they survive a crash
+// or power loss, e.g. before deleting the source they were copied from. It is
+// only needed when writes don't sync themselves, such as with
+// Config.FileNoSyncOnWrite. It returns ErrNotSupported if the backend doesn't
+// implement Syncer; the OS credential stores persist writes themselves.
+func Sync(kr Keyring) error {
+	if s, ok := as[Syncer](kr); ok {
+		return s.Sync()
+	}
+	return ErrNotSupported
+}
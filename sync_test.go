@@ -0,0 +1,34 @@
+package keyring
+
+import "testing"
+
+func TestSyncFileKeyring(t *testing.T) {
+	k := &fileKeyring{
+		dir:           t.TempDir(),
+		passwordFunc:  FixedStringPrompt("no more secrets"),
+		noSyncOnWrite: true,
+	}
+	for _, key := range []string{"llamas", "alpacas"} {
+		if err := k.Set(Item{Key: key, Data: []byte(key + " are great")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Sync(k); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected data %q", item.Data)
+	}
+}
+
+func TestSyncNotSupported(t *testing.T) {
+	if err := Sync(NewArrayKeyring(nil)); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
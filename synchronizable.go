@@ -0,0 +1,37 @@
+package keyring
+
+// SynchronizableKeyLister is implemented by backends that can tell which items
+// follow the user to other devices.
+//
+// Keychain queries have three synchronizable modes. Without the attribute, as
+// Keys and Get query, only device-local items match. With SynchronizableYes
+// only items synchronized through iCloud Keychain match, which is what
+// KeysSynchronizable queries. With SynchronizableAny both match. Synchronized
+// items live in the iCloud keychain rather than a keychain file, so the list
+// is empty when a KeychainName is configured.
+type SynchronizableKeyLister interface {
+	// Provides a slice of the keys of synchronized items only
+	KeysSynchronizable() ([]string, error)
+}
+
+// SynchronizableDeduplicator is implemented by backends that can clean up keys
+// stored both as a synchronized and as a device-local item.
+type SynchronizableDeduplicator interface {
+	// Removes the copy that isn't preferred of every key stored both ways,
+	// returning how many items were removed
+	DeduplicateSynchronizable(preferSynchronizable bool) (int, error)
+}
+
+// DeduplicateSynchronizable removes one copy of every key that kr stores both
+// as a synchronized and as a device-local item, which happens when an item
+// was written before and after KeychainSynchronizable was changed. The
+// synchronized copy is kept if preferSynchronizable, otherwise the local one.
+// The copy removed is lost, even if its data was newer. Failures for
+// individual keys are collected into a RemoveError. It returns
+// ErrNotSupported if the backend doesn't implement SynchronizableDeduplicator.
+func DeduplicateSynchronizable(kr Keyring, preferSynchronizable bool) (int, error) {
+	if d, ok := as[SynchronizableDeduplicator](kr); ok {
+		return d.DeduplicateSynchronizable(preferSynchronizable)
+	}
+	return 0, ErrNotSupported
+}
@@ -0,0 +1,94 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStaleFastTier is returned by a tiered keyring's Set when authoritative
+// has the new item but fast still holds the one it replaced, as fast could
+// neither be written nor have the item removed.
+var ErrStaleFastTier = errors.New("The fast tier still holds the replaced item")
+
+// tieredKeyring reads through a fast Keyring to an authoritative one, see NewTiered.
+type tieredKeyring struct {
+	fast          Keyring
+	authoritative Keyring
+}
+
+// NewTiered returns a Keyring that reads items from fast when it has them and
+// from authoritative otherwise, copying items it reads from authoritative into
+// fast. Set and Remove write through to both, authoritative first, so that
+// fast never holds an item that authoritative rejected. Keys and GetMetadata
+// only read authoritative.
+//
+// A typical fast tier is an ArrayKeyring, or a file keyring to keep it across
+// restarts, in front of the keychain or another backend that is slow or
+// prompts. Items changed in authoritative by other processes aren't seen
+// until they are removed from fast. The extensions of either tier, such as
+// Reset or RemoveAll, aren't reachable through the returned Keyring, as they
+// would bypass the other tier.
+func NewTiered(fast, authoritative Keyring) Keyring {
+	return &tieredKeyring{fast: fast, authoritative: authoritative}
+}
+
+// Get falls back to authoritative on any error from fast, and returns the item
+// even when it can't be copied into fast.
+func (k *tieredKeyring) Get(key string) (Item, error) {
+	item, err := k.fast.Get(key)
+	if err == nil {
+		return item, nil
+	} else if !errors.Is(err, ErrKeyNotFound) {
+		debugf("Reading %q from the fast tier failed: %v", key, err)
+	}
+
+	item, err = k.authoritative.Get(key)
+	if err != nil {
+		return Item{}, err
+	}
+
+	if err := k.fast.Set(item); err != nil {
+		debugf("Copying %q to the fast tier failed: %v", key, err)
+	}
+	return item, nil
+}
+
+func (k *tieredKeyring) GetMetadata(key string) (Metadata, error) {
+	return k.authoritative.GetMetadata(key)
+}
+
+// Set succeeds once authoritative has the item. If it can't be written to
+// fast, the item is removed from fast instead, so that fast doesn't keep
+// serving the value it replaced. If that fails too, Set returns an error
+// matching ErrStaleFastTier, as Get would return the replaced value.
+func (k *tieredKeyring) Set(item Item) error {
+	if err := k.authoritative.Set(item); err != nil {
+		return err
+	}
+
+	if err := k.fast.Set(item); err != nil {
+		debugf("Writing %q to the fast tier failed: %v", item.Key, err)
+		if removeErr := k.fast.Remove(item.Key); removeErr != nil && !errors.Is(removeErr, ErrKeyNotFound) {
+			return fmt.Errorf("%w: %s: writing failed: %v, removing failed: %v", ErrStaleFastTier, item.Key, err, removeErr)
+		}
+	}
+	return nil
+}
+
+// Remove removes the item from fast even when authoritative doesn't have it,
+// and returns authoritative's error.
+func (k *tieredKeyring) Remove(key string) error {
+	err := k.authoritative.Remove(key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	if fastErr := k.fast.Remove(key); fastErr != nil && !errors.Is(fastErr, ErrKeyNotFound) {
+		return fastErr
+	}
+	return err
+}
+
+func (k *tieredKeyring) Keys() ([]string, error) {
+	return k.authoritative.Keys()
+}
@@ -0,0 +1,124 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTieredKeyringPopulatesFastOnMiss(t *testing.T) {
+	fast := NewArrayKeyring(nil)
+	authoritative := NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}})
+	k := NewTiered(fast, authoritative)
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Unexpected data %q", item.Data)
+	}
+
+	cached, err := fast.Get("llamas")
+	if err != nil {
+		t.Fatalf("Expected the item to be copied to the fast tier, got %v", err)
+	}
+	if string(cached.Data) != "llamas are great" {
+		t.Fatalf("Unexpected cached data %q", cached.Data)
+	}
+
+	// later reads are served by the fast tier
+	if err := authoritative.Set(Item{Key: "llamas", Data: []byte("changed elsewhere")}); err != nil {
+		t.Fatal(err)
+	}
+	if item, _ := k.Get("llamas"); string(item.Data) != "llamas are great" {
+		t.Fatalf("Expected the fast tier's item, got %q", item.Data)
+	}
+
+	if _, err := k.Get("alpacas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestTieredKeyringWritesThrough(t *testing.T) {
+	fast := NewArrayKeyring(nil)
+	authoritative := NewArrayKeyring(nil)
+	k := NewTiered(fast, authoritative)
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+	for name, tier := range map[string]Keyring{"fast": fast, "authoritative": authoritative} {
+		if _, err := tier.Get("llamas"); err != nil {
+			t.Fatalf("Expected the %s tier to have the item, got %v", name, err)
+		}
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "llamas" {
+		t.Fatalf("Expected [llamas], got %v", keys)
+	}
+
+	if err := k.Remove("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	for name, tier := range map[string]Keyring{"fast": fast, "authoritative": authoritative} {
+		if _, err := tier.Get("llamas"); err != ErrKeyNotFound {
+			t.Fatalf("Expected the %s tier to have removed the item, got %v", name, err)
+		}
+	}
+}
+
+// readOnlyKeyring fails every write, like a fast tier on a full disk.
+type readOnlyKeyring struct {
+	*ArrayKeyring
+}
+
+func (k readOnlyKeyring) Set(item Item) error {
+	return errors.New("read only")
+}
+
+func TestTieredKeyringSetIgnoresFastTierFailure(t *testing.T) {
+	fast := readOnlyKeyring{NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("stale")}})}
+	authoritative := NewArrayKeyring(nil)
+	k := NewTiered(fast, authoritative)
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatalf("Expected the write to authoritative to succeed, got %v", err)
+	}
+	if _, err := fast.Get("llamas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected the stale item to be removed from the fast tier, got %v", err)
+	}
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" {
+		t.Fatalf("Expected the authoritative item, got %q", item.Data)
+	}
+}
+
+// stuckKeyring can't be written to or removed from.
+type stuckKeyring struct {
+	readOnlyKeyring
+}
+
+func (k stuckKeyring) Remove(key string) error {
+	return errors.New("read only")
+}
+
+func TestTieredKeyringSetReportsStaleFastTier(t *testing.T) {
+	fast := stuckKeyring{readOnlyKeyring{NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("stale")}})}}
+	authoritative := NewArrayKeyring(nil)
+	k := NewTiered(fast, authoritative)
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); !errors.Is(err, ErrStaleFastTier) {
+		t.Fatalf("Expected ErrStaleFastTier, got %v", err)
+	}
+	if item, err := authoritative.Get("llamas"); err != nil || string(item.Data) != "llamas are great" {
+		t.Fatalf("Expected authoritative to have the item, got %q, %v", item.Data, err)
+	}
+}
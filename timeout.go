@@ -0,0 +1,99 @@
+package keyring
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrOperationTimeout is returned by a Keyring from NewTimeout when an operation takes too long.
+var ErrOperationTimeout = errors.New("The keyring operation timed out")
+
+// timeoutKeyring bounds every operation on the decorated Keyring, see NewTimeout.
+type timeoutKeyring struct {
+	Keyring
+	timeout time.Duration
+}
+
+// NewTimeout returns a Keyring that returns ErrOperationTimeout when an
+// operation on inner takes longer than d.
+//
+// None of the backends can be canceled, so an operation that times out keeps
+// running in its goroutine until the backend returns, e.g. until the user
+// answers an unlock prompt, and its result is discarded. A Set that times out
+// may therefore still be written afterwards.
+func NewTimeout(inner Keyring, d time.Duration) Keyring {
+	return &timeoutKeyring{inner, d}
+}
+
+// withTimeout runs op in a goroutine and waits at most d for it to return.
+func withTimeout[T any](d time.Duration, op func() (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+
+	// buffered so that a goroutine that outlives the timeout doesn't block forever
+	done := make(chan result, 1)
+	go func() {
+		v, err := op()
+		done <- result{v, err}
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-timer.C:
+		var zero T
+		return zero, ErrOperationTimeout
+	}
+}
+
+func (k *timeoutKeyring) Get(key string) (Item, error) {
+	return withTimeout(k.timeout, func() (Item, error) {
+		return k.Keyring.Get(key)
+	})
+}
+
+func (k *timeoutKeyring) GetMetadata(key string) (Metadata, error) {
+	return withTimeout(k.timeout, func() (Metadata, error) {
+		return k.Keyring.GetMetadata(key)
+	})
+}
+
+func (k *timeoutKeyring) Set(item Item) error {
+	_, err := withTimeout(k.timeout, func() (struct{}, error) {
+		return struct{}{}, k.Keyring.Set(item)
+	})
+	return err
+}
+
+func (k *timeoutKeyring) SetWithOptions(item Item, opts ...SetOption) error {
+	_, err := withTimeout(k.timeout, func() (struct{}, error) {
+		return struct{}{}, SetWithOptions(k.Keyring, item, opts...)
+	})
+	return err
+}
+
+func (k *timeoutKeyring) SetResult(item Item) (SetOutcome, error) {
+	return withTimeout(k.timeout, func() (SetOutcome, error) {
+		return SetResult(k.Keyring, item)
+	})
+}
+
+func (k *timeoutKeyring) Remove(key string) error {
+	_, err := withTimeout(k.timeout, func() (struct{}, error) {
+		return struct{}{}, k.Keyring.Remove(key)
+	})
+	return err
+}
+
+func (k *timeoutKeyring) Keys() ([]string, error) {
+	return withTimeout(k.timeout, k.Keyring.Keys)
+}
+
+func (k *timeoutKeyring) Unwrap() Keyring {
+	return k.Keyring
+}
@@ -0,0 +1,36 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+)
+
+// blockingKeyring blocks every Get until release is closed.
+type blockingKeyring struct {
+	*ArrayKeyring
+	release chan struct{}
+}
+
+func (k blockingKeyring) Get(key string) (Item, error) {
+	<-k.release
+	return k.ArrayKeyring.Get(key)
+}
+
+func TestTimeoutKeyring(t *testing.T) {
+	inner := blockingKeyring{NewArrayKeyring([]Item{{Key: "llamas", Data: []byte("llamas are great")}}), make(chan struct{})}
+	defer close(inner.release)
+
+	k := NewTimeout(inner, 10*time.Millisecond)
+
+	if _, err := k.Get("llamas"); err != ErrOperationTimeout {
+		t.Fatalf("Expected ErrOperationTimeout, got %v", err)
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "llamas" {
+		t.Fatalf("Expected [llamas], got %v", keys)
+	}
+}
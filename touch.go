@@ -0,0 +1,33 @@
+package keyring
+
+import (
+	"os"
+	"time"
+)
+
+// Toucher is implemented by backends that can mark an item as recently used
+// by bumping the modification time reported by GetMetadata, without reading
+// or rewriting the secret.
+type Toucher interface {
+	// Updates the modification time of the item with matching key or returns ErrKeyNotFound
+	Touch(key string) error
+}
+
+// Touch bumps the modification time of the item with matching key, or
+// returns ErrNotSupported if kr can't do so without rewriting the item.
+func Touch(kr Keyring, key string) error {
+	if t, ok := as[Toucher](kr); ok {
+		return t.Touch(key)
+	}
+	return ErrNotSupported
+}
+
+// touchFile sets the modification time of filename to now.
+func touchFile(filename string) error {
+	now := time.Now()
+	err := os.Chtimes(filename, now, now)
+	if os.IsNotExist(err) {
+		return ErrKeyNotFound
+	}
+	return err
+}
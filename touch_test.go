@@ -0,0 +1,49 @@
+package keyring
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileKeyringTouch(t *testing.T) {
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+
+	if err := Touch(k, "llamas"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")}); err != nil {
+		t.Fatal(err)
+	}
+
+	filename, err := k.filename("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err = os.Chtimes(filename, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Touch(k, "llamas"); err != nil {
+		t.Fatal(err)
+	}
+
+	md, err := k.GetMetadata("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !md.ModificationTime.After(past.Add(time.Minute)) {
+		t.Fatalf("Modification time wasn't bumped: %v", md.ModificationTime)
+	}
+}
+
+func TestTouchNotSupported(t *testing.T) {
+	if err := Touch(NewArrayKeyring(nil), "llamas"); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package keyring
+
+// Unlocker is implemented by backends that can be unlocked ahead of their first use.
+type Unlocker interface {
+	// Unlocks the store, prompting for a password if needed
+	Unlock() error
+}
+
+// Unlock unlocks the store behind kr now, rather than on the first operation
+// that needs it, so that a daemon can choose when the user is prompted and
+// find out straight away if unlocking fails, typically with ErrLocked or an
+// authentication error. Operations still unlock lazily without it. It
+// returns ErrNotSupported if the backend doesn't implement Unlocker.
+func Unlock(kr Keyring) error {
+	if u, ok := as[Unlocker](kr); ok {
+		return u.Unlock()
+	}
+	return ErrNotSupported
+}
@@ -0,0 +1,27 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnlockNotSupported(t *testing.T) {
+	if err := Unlock(NewArrayKeyring(nil)); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestUnlockThroughDecorator(t *testing.T) {
+	prompts := 0
+	file := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: sequencePrompt(&prompts, "no more secrets"),
+	}
+
+	if err := Unlock(&caseInsensitiveKeyring{forwarding{file}}); err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 1 {
+		t.Fatalf("Expected the file keyring to prompt once, got %d", prompts)
+	}
+}
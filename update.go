@@ -0,0 +1,45 @@
+package keyring
+
+import "errors"
+
+// errUpdateChangedKey is returned when an Update mutation changes the item's key.
+var errUpdateChangedKey = errors.New("Update can't change the key of an item, use Set and Remove")
+
+// Updater is implemented by backends that can modify an existing item in place.
+type Updater interface {
+	// Reads the item with matching key, applies mutate and writes it back, or returns ErrKeyNotFound
+	Update(key string, mutate func(*Item) error) error
+}
+
+// Update reads the item with matching key, applies mutate to it and writes it
+// back, so that fields mutate doesn't touch are preserved. It returns
+// ErrKeyNotFound rather than creating the item.
+//
+// Backends without an Updater are updated with Get then Set, which isn't
+// atomic: a concurrent write between the two is lost.
+func Update(kr Keyring, key string, mutate func(*Item) error) error {
+	if u, ok := as[Updater](kr); ok {
+		return u.Update(key, mutate)
+	}
+
+	item, err := kr.Get(key)
+	if err != nil {
+		return err
+	}
+	if err = applyUpdate(&item, mutate); err != nil {
+		return err
+	}
+	return kr.Set(item)
+}
+
+// applyUpdate applies mutate to item, refusing changes to its key.
+func applyUpdate(item *Item, mutate func(*Item) error) error {
+	key := item.Key
+	if err := mutate(item); err != nil {
+		return err
+	}
+	if item.Key != key {
+		return errUpdateChangedKey
+	}
+	return nil
+}
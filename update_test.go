@@ -0,0 +1,44 @@
+package keyring
+
+import "testing"
+
+func TestUpdatePreservesFields(t *testing.T) {
+	k := NewArrayKeyring([]Item{{
+		Key:         "llamas",
+		Label:       "Llamas",
+		Description: "A llama",
+		Data:        []byte("llamas are ok"),
+	}})
+
+	err := Update(k, "llamas", func(item *Item) error {
+		item.Data = []byte("llamas are great")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "llamas are great" || item.Label != "Llamas" || item.Description != "A llama" {
+		t.Fatalf("Unexpected item after update: %#v", item)
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	k := NewArrayKeyring([]Item{{Key: "llamas"}})
+
+	if err := Update(k, "alpacas", func(*Item) error { return nil }); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	err := Update(k, "llamas", func(item *Item) error {
+		item.Key = "alpacas"
+		return nil
+	})
+	if err != errUpdateChangedKey {
+		t.Fatalf("Expected errUpdateChangedKey, got %v", err)
+	}
+}
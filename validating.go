@@ -0,0 +1,135 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrInvalidKey is returned when a key doesn't satisfy the keyring's naming policy.
+var ErrInvalidKey = errors.New("The key does not satisfy the keyring naming policy")
+
+// ErrEmptyKey is returned by the backends for an empty key, unless Config.AllowEmptyKeys is set.
+var ErrEmptyKey = errors.New("The key is empty")
+
+// InvalidKeyError describes a key rejected by a naming policy. It matches
+// ErrInvalidKey with errors.Is and unwraps to the policy's error.
+type InvalidKeyError struct {
+	Key string
+	Err error
+}
+
+func (e *InvalidKeyError) Error() string {
+	return fmt.Sprintf("invalid key %q: %v", e.Key, e.Err)
+}
+
+func (e *InvalidKeyError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InvalidKeyError) Is(target error) bool {
+	return target == ErrInvalidKey
+}
+
+// NewValidating returns a Keyring that checks every key written through Set
+// against validate and returns an InvalidKeyError when it is rejected.
+//
+// Get, GetMetadata and Remove are passed through unchecked so that items
+// stored before the policy was introduced can still be read and cleaned up.
+// Update is checked like Set, and ImportEncrypted isn't supported, as it
+// would store the keys in the export unchecked.
+func NewValidating(inner Keyring, validate func(key string) error) Keyring {
+	return &validatingKeyring{
+		forwarding: forwarding{inner},
+		validate:   validate,
+	}
+}
+
+type validatingKeyring struct {
+	forwarding
+	validate func(key string) error
+}
+
+func (k *validatingKeyring) Set(item Item) error {
+	if err := k.validate(item.Key); err != nil {
+		return &InvalidKeyError{Key: item.Key, Err: err}
+	}
+	return k.Keyring.Set(item)
+}
+
+func (k *validatingKeyring) SetWithOptions(item Item, opts ...SetOption) error {
+	if err := k.validate(item.Key); err != nil {
+		return &InvalidKeyError{Key: item.Key, Err: err}
+	}
+	return SetWithOptions(k.Keyring, item, opts...)
+}
+
+func (k *validatingKeyring) SetResult(item Item) (SetOutcome, error) {
+	if err := k.validate(item.Key); err != nil {
+		return 0, &InvalidKeyError{Key: item.Key, Err: err}
+	}
+	return SetResult(k.Keyring, item)
+}
+
+func (k *validatingKeyring) Update(key string, mutate func(*Item) error) error {
+	if err := k.validate(key); err != nil {
+		return &InvalidKeyError{Key: key, Err: err}
+	}
+	return Update(k.Keyring, key, mutate)
+}
+
+func (k *validatingKeyring) Touch(key string) error {
+	return Touch(k.Keyring, key)
+}
+
+func (k *validatingKeyring) KeysByCategory(category string) ([]string, error) {
+	return KeysByCategory(k.Keyring, category)
+}
+
+func (k *validatingKeyring) KeysWithFlags() ([]KeyInfo, error) {
+	return KeysWithFlags(k.Keyring)
+}
+
+func (k *validatingKeyring) Entries() ([]Metadata, error) {
+	return Entries(k.Keyring)
+}
+
+func (k *validatingKeyring) RemoveAll() error {
+	return RemoveAll(k.Keyring)
+}
+
+func (k *validatingKeyring) RemoveMatching(pred func(key string) bool) (int, error) {
+	return RemoveMatching(k.Keyring, pred)
+}
+
+func (k *validatingKeyring) GetVersion(key string, n int) (Item, error) {
+	return GetVersion(k.Keyring, key, n)
+}
+
+func (k *validatingKeyring) Versions(key string) ([]Metadata, error) {
+	return Versions(k.Keyring, key)
+}
+
+// checkKey returns ErrEmptyKey for an empty key unless allowEmpty, see Config.AllowEmptyKeys.
+func checkKey(key string, allowEmpty bool) error {
+	if key == "" && !allowEmpty {
+		return ErrEmptyKey
+	}
+	return nil
+}
+
+// RegexpKeyValidator returns a validator for NewValidating that requires keys
+// to match pattern and, when maxLen is greater than 0, be at most maxLen bytes.
+// It panics if pattern can't be compiled.
+func RegexpKeyValidator(pattern string, maxLen int) func(key string) error {
+	re := regexp.MustCompile(pattern)
+	return func(key string) error {
+		if maxLen > 0 && len(key) > maxLen {
+			return fmt.Errorf("key is %d bytes, longer than the maximum of %d", len(key), maxLen)
+		}
+		if !re.MatchString(key) {
+			return fmt.Errorf("key does not match %s", re)
+		}
+		return nil
+	}
+}
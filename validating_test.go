@@ -0,0 +1,127 @@
+package keyring
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestValidatingKeyringRejectsInvalidKeys(t *testing.T) {
+	inner := NewArrayKeyring([]Item{{Key: "Legacy Key", Data: []byte("legacy")}})
+	k := NewValidating(inner, RegexpKeyValidator(`^[a-z0-9/_-]+$`, 16))
+
+	if err := k.Set(Item{Key: "aws/default", Data: []byte("llamas")}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"Not Valid", "much/too/long/for/the/policy"} {
+		err := k.Set(Item{Key: key, Data: []byte("llamas")})
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+		if _, err := inner.Get(key); err != ErrKeyNotFound {
+			t.Fatalf("Invalid key %q was written to the inner keyring", key)
+		}
+	}
+
+	// Keys stored before the policy remain readable and removable
+	if _, err := k.Get("Legacy Key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Remove("Legacy Key"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidatingKeyringUnwraps(t *testing.T) {
+	inner := NewArrayKeyring([]Item{{Key: "llamas"}})
+	k := NewValidating(inner, RegexpKeyValidator(`.*`, 0))
+
+	if err := RemoveAll(k); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := inner.Keys(); len(keys) != 0 {
+		t.Fatalf("Expected bulk removal to reach the inner keyring, got %v", keys)
+	}
+}
+
+func TestValidatingKeyringUpdate(t *testing.T) {
+	inner := NewArrayKeyring([]Item{{Key: "Legacy Key"}})
+	k := NewValidating(inner, RegexpKeyValidator(`^[a-z]+$`, 0))
+
+	err := Update(k, "Legacy Key", func(item *Item) error {
+		item.Data = []byte("llamas are great")
+		return nil
+	})
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Expected ErrInvalidKey, got %v", err)
+	}
+	if item, _ := inner.Get("Legacy Key"); len(item.Data) != 0 {
+		t.Fatalf("Expected the item to be left alone, got %q", item.Data)
+	}
+
+	if BackendTypeOf(NewValidating(&fileKeyring{}, RegexpKeyValidator(`.*`, 0))) != FileBackend {
+		t.Fatal("Expected the backend type to be passed through")
+	}
+}
+
+func TestEmptyKeysRejectedByDefault(t *testing.T) {
+	k := &fileKeyring{dir: t.TempDir(), passwordFunc: FixedStringPrompt("no more secrets")}
+
+	if err := k.Set(Item{Key: "", Data: []byte("llamas are great")}); err != ErrEmptyKey {
+		t.Fatalf("Expected ErrEmptyKey from Set, got %v", err)
+	}
+	if _, err := k.Get(""); err != ErrEmptyKey {
+		t.Fatalf("Expected ErrEmptyKey from Get, got %v", err)
+	}
+	if _, err := k.GetMetadata(""); err != ErrEmptyKey {
+		t.Fatalf("Expected ErrEmptyKey from GetMetadata, got %v", err)
+	}
+	if err := k.Remove(""); err != ErrEmptyKey {
+		t.Fatalf("Expected ErrEmptyKey from Remove, got %v", err)
+	}
+	if err := k.Touch(""); err != ErrEmptyKey {
+		t.Fatalf("Expected ErrEmptyKey from Touch, got %v", err)
+	}
+}
+
+func TestEmptyKeysAllowed(t *testing.T) {
+	if err := checkKey("", true); err != nil {
+		t.Fatalf("Expected an empty key to be allowed, got %v", err)
+	}
+
+	dir := t.TempDir()
+	k := &fileKeyring{dir: dir, passwordFunc: FixedStringPrompt("no more secrets"), allowEmptyKeys: true}
+	for _, key := range []string{"", ".", ".."} {
+		if err := k.Set(Item{Key: key, Data: []byte("llamas are great")}); err != nil {
+			t.Fatalf("Expected %q to be stored, got %v", key, err)
+		}
+		item, err := k.Get(key)
+		if err != nil {
+			t.Fatalf("Expected %q to be read back, got %v", key, err)
+		}
+		if string(item.Data) != "llamas are great" {
+			t.Fatalf("Value stored for %q was not the value retrieved: %q", key, item.Data)
+		}
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"", ".", ".."}) {
+		t.Fatalf("Unexpected keys %q", keys)
+	}
+
+	for _, key := range []string{"", ".", ".."} {
+		if err := k.Remove(key); err != nil {
+			t.Fatalf("Expected %q to be removed, got %v", key, err)
+		}
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("Expected the directory to be kept, got %v", err)
+	}
+}
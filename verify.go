@@ -0,0 +1,63 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrWriteVerificationFailed is returned when an item read back after Set doesn't match what was written.
+var ErrWriteVerificationFailed = errors.New("The item read back from the keyring does not match the item written")
+
+// verifyingKeyring reads every item back after Set, see Config.VerifyOnWrite.
+// It always reads the data with Get. No backend can check the stored length
+// from metadata alone, so there is no cheaper path to prefer.
+type verifyingKeyring struct {
+	Keyring
+}
+
+func (k *verifyingKeyring) Set(item Item) error {
+	if err := k.Keyring.Set(item); err != nil {
+		return err
+	}
+	return k.verify(item)
+}
+
+func (k *verifyingKeyring) SetWithOptions(item Item, opts ...SetOption) error {
+	if err := SetWithOptions(k.Keyring, item, opts...); err != nil {
+		return err
+	}
+	return k.verify(item)
+}
+
+func (k *verifyingKeyring) SetResult(item Item) (SetOutcome, error) {
+	outcome, err := SetResult(k.Keyring, item)
+	if err != nil {
+		return outcome, err
+	}
+	return outcome, k.verify(item)
+}
+
+// verify reads item back after it was written.
+func (k *verifyingKeyring) verify(item Item) error {
+	stored, err := k.Keyring.Get(item.Key)
+	if err == ErrKeyNotFound {
+		debugf("Item %q missing after Set", item.Key)
+		return ErrWriteVerificationFailed
+	} else if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(stored.Data, item.Data) {
+		debugf("Item %q read back with different data after Set", item.Key)
+		return ErrWriteVerificationFailed
+	}
+	return nil
+}
+
+func (k *verifyingKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return GetWithCallback(k.Keyring, key, fn)
+}
+
+func (k *verifyingKeyring) Unwrap() Keyring {
+	return k.Keyring
+}
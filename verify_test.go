@@ -0,0 +1,28 @@
+package keyring
+
+import "testing"
+
+// lossyKeyring drops every write after the first for a key.
+type lossyKeyring struct {
+	*ArrayKeyring
+}
+
+func (k lossyKeyring) Set(item Item) error {
+	if _, err := k.ArrayKeyring.Get(item.Key); err == nil {
+		return nil
+	}
+	return k.ArrayKeyring.Set(item)
+}
+
+func TestVerifyingKeyringSet(t *testing.T) {
+	k := &verifyingKeyring{lossyKeyring{NewArrayKeyring(nil)}}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas are ok")}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := k.Set(Item{Key: "llamas", Data: []byte("llamas are great")})
+	if err != ErrWriteVerificationFailed {
+		t.Fatalf("Expected ErrWriteVerificationFailed, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package keyring
+
+// Versioner is implemented by backends that keep the previous values of an
+// item when it is overwritten.
+type Versioner interface {
+	// Returns version n of the item, where 0 is the current item, 1 the one it
+	// replaced and so on
+	GetVersion(key string, n int) (Item, error)
+
+	// Returns the metadata of every kept version of the item, indexed like GetVersion
+	Versions(key string) ([]Metadata, error)
+}
+
+// GetVersion returns version n of the item with the given key, where 0 is the
+// current item and 1 the value it replaced, e.g. to roll back a bad rotation
+// by Setting it again. It returns ErrKeyNotFound if fewer versions are kept,
+// and ErrNotSupported if the backend doesn't implement Versioner.
+func GetVersion(kr Keyring, key string, n int) (Item, error) {
+	if v, ok := as[Versioner](kr); ok {
+		return v.GetVersion(key, n)
+	}
+	return Item{}, ErrNotSupported
+}
+
+// Versions returns the metadata of the kept versions of the item with the given
+// key, newest first, so that index n describes GetVersion(kr, key, n). It
+// returns ErrNotSupported if the backend doesn't implement Versioner.
+func Versions(kr Keyring, key string) ([]Metadata, error) {
+	if v, ok := as[Versioner](kr); ok {
+		return v.Versions(key)
+	}
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,160 @@
+package keyring
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileKeyringVersions(t *testing.T) {
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: FixedStringPrompt("no more secrets"),
+		keepVersions: 2,
+	}
+
+	for i := 1; i <= 4; i++ {
+		item := Item{Key: "llamas", Data: []byte(fmt.Sprintf("llamas v%d", i)), Label: fmt.Sprintf("v%d", i)}
+		if err := k.Set(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	current, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current.Data) != "llamas v4" {
+		t.Fatalf("Expected the latest value, got %q", current.Data)
+	}
+
+	// v1 was pruned, only the two values before the current one are kept
+	for n, want := range []string{"llamas v4", "llamas v3", "llamas v2"} {
+		item, err := GetVersion(k, "llamas", n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(item.Data) != want {
+			t.Fatalf("Expected version %d to be %q, got %q", n, want, item.Data)
+		}
+	}
+	if _, err := GetVersion(k, "llamas", 3); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound for a pruned version, got %v", err)
+	}
+
+	versions, err := Versions(k, "llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0].Label != "v4" || versions[2].Label != "v2" {
+		t.Fatalf("Unexpected versions %+v", versions)
+	}
+
+	keys, err := k.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "llamas" {
+		t.Fatalf("Expected the kept versions to be hidden from Keys, got %v", keys)
+	}
+}
+
+func TestFileKeyringVersionRollback(t *testing.T) {
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: FixedStringPrompt("no more secrets"),
+		keepVersions: 1,
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("good")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("bad")}); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := GetVersion(k, "llamas", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Set(previous); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current.Data) != "good" {
+		t.Fatalf("Expected the rolled back value, got %q", current.Data)
+	}
+
+	if err := k.Remove("llamas"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetVersion(k, "llamas", 1); err != ErrKeyNotFound {
+		t.Fatalf("Expected Remove to delete the kept versions, got %v", err)
+	}
+}
+
+func TestFileKeyringVersionsFailedWrite(t *testing.T) {
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: FixedStringPrompt("no more secrets"),
+		keepVersions: 2,
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas v1")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory in the way of the pending file makes the write fail
+	versionsDir, err := k.versionsDir("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(versionsDir, fileVersionPending, "blocked"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.Set(Item{Key: "llamas", Data: []byte("llamas v2")}); err == nil {
+		t.Fatal("Expected the write to fail")
+	}
+
+	current, err := k.Get("llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current.Data) != "llamas v1" {
+		t.Fatalf("Expected the previous value to be left in place, got %q", current.Data)
+	}
+	if versions, err := k.Versions("llamas"); err != nil || len(versions) != 1 {
+		t.Fatalf("Expected no version to be kept, got %v, %v", versions, err)
+	}
+}
+
+func TestFileKeyringWithoutVersions(t *testing.T) {
+	k := &fileKeyring{
+		dir:          t.TempDir(),
+		passwordFunc: FixedStringPrompt("no more secrets"),
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("v1")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Set(Item{Key: "llamas", Data: []byte("v2")}); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := Versions(k, "llamas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("Expected only the current version, got %d", len(versions))
+	}
+}
+
+func TestVersionsNotSupported(t *testing.T) {
+	if _, err := Versions(NewArrayKeyring(nil), "llamas"); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
@@ -4,6 +4,8 @@
 package keyring
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"syscall"
 
@@ -13,13 +15,18 @@ import (
 // ERROR_NOT_FOUND from https://docs.microsoft.com/en-us/windows/win32/debug/system-error-codes--1000-1299-
 const elementNotFoundError = syscall.Errno(1168)
 
+// CRED_MAX_CREDENTIAL_BLOB_SIZE from https://docs.microsoft.com/en-us/windows/win32/api/wincred/ns-wincred-credentialw
+const maxCredentialBlobSize = 5 * 512
+
 type windowsKeyring struct {
-	name   string
-	prefix string
+	name           string
+	prefix         string
+	persist        wincred.CredentialPersistence
+	allowEmptyKeys bool
 }
 
 func init() {
-	supportedBackends[WinCredBackend] = opener(func(cfg Config) (Keyring, error) {
+	supportedBackends[WinCredBackend] = OpenerFunc(func(cfg Config) (Keyring, error) {
 		name := cfg.ServiceName
 		if name == "" {
 			name = "default"
@@ -30,14 +37,25 @@ func init() {
 			prefix = "keyring"
 		}
 
+		persist, err := getWinCredPersistence(cfg.WinCredPersist)
+		if err != nil {
+			return nil, err
+		}
+
 		return &windowsKeyring{
-			name:   name,
-			prefix: prefix,
+			name:           name,
+			prefix:         prefix,
+			persist:        persist,
+			allowEmptyKeys: cfg.AllowEmptyKeys,
 		}, nil
 	})
 }
 
 func (k *windowsKeyring) Get(key string) (Item, error) {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return Item{}, err
+	}
+
 	cred, err := wincred.GetGenericCredential(k.credentialName(key))
 	if err != nil {
 		if err == elementNotFoundError {
@@ -46,14 +64,24 @@ func (k *windowsKeyring) Get(key string) (Item, error) {
 		return Item{}, err
 	}
 
+	data, parts, err := unframeData(cred.CredentialBlob)
+	if err != nil {
+		return Item{}, err
+	}
+
 	item := Item{
-		Key:  key,
-		Data: cred.CredentialBlob,
+		Key:       key,
+		Data:      data,
+		DataParts: parts,
 	}
 
 	return item, nil
 }
 
+func (k *windowsKeyring) GetWithCallback(key string, fn func(data []byte) error) error {
+	return getWithCallback(k.Get, key, fn)
+}
+
 // GetMetadata for pass returns an error indicating that it's unsupported
 // for this backend.
 // TODO: This is a stub. Look into whether pass would support metadata in a usable way for keyring.
@@ -62,12 +90,31 @@ func (k *windowsKeyring) GetMetadata(_ string) (Metadata, error) {
 }
 
 func (k *windowsKeyring) Set(item Item) error {
+	if err := checkKey(item.Key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
+	data, err := frameData(item)
+	if err != nil {
+		return err
+	}
+	item.Data, item.DataParts = data, nil
+
+	if err := checkValueSize(item, k.MaxValueSize()); err != nil {
+		return err
+	}
+
 	cred := wincred.NewGenericCredential(k.credentialName(item.Key))
 	cred.CredentialBlob = item.Data
+	cred.Persist = k.persist
 	return cred.Write()
 }
 
 func (k *windowsKeyring) Remove(key string) error {
+	if err := checkKey(key, k.allowEmptyKeys); err != nil {
+		return err
+	}
+
 	cred, err := wincred.GetGenericCredential(k.credentialName(key))
 	if err != nil {
 		if err == elementNotFoundError {
@@ -93,6 +140,67 @@ func (k *windowsKeyring) Keys() ([]string, error) {
 	return results, nil
 }
 
+func (k *windowsKeyring) BackendType() BackendType {
+	return WinCredBackend
+}
+
+// GetRawTarget reads a generic credential by its full target name. Other
+// credential types, such as domain passwords from `cmdkey /add:`, can't be
+// read back by applications and return ErrKeyNotFound.
+func (k *windowsKeyring) GetRawTarget(target string) (Item, error) {
+	cred, err := wincred.GetGenericCredential(target)
+	if err != nil {
+		if err == elementNotFoundError {
+			return Item{}, ErrKeyNotFound
+		}
+		return Item{}, err
+	}
+
+	data, parts, err := unframeData(cred.CredentialBlob)
+	if err != nil {
+		return Item{}, err
+	}
+
+	return Item{
+		Key:         target,
+		Data:        data,
+		DataParts:   parts,
+		Description: cred.Comment,
+	}, nil
+}
+
+// ListAllTargets returns the error from listing credentials, which Keys ignores.
+func (k *windowsKeyring) ListAllTargets() ([]string, error) {
+	creds, err := wincred.List()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]string, 0, len(creds))
+	for _, cred := range creds {
+		targets = append(targets, cred.TargetName)
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// MaxValueSize is the largest credential blob Windows will store.
+func (k *windowsKeyring) MaxValueSize() int {
+	return maxCredentialBlobSize
+}
+
+func getWinCredPersistence(persist string) (wincred.CredentialPersistence, error) {
+	switch persist {
+	case "", "local_machine":
+		return wincred.PersistLocalMachine, nil
+	case "session":
+		return wincred.PersistSession, nil
+	case "enterprise":
+		return wincred.PersistEnterprise, nil
+	}
+	return 0, fmt.Errorf("unknown wincred persistence %q", persist)
+}
+
 func (k *windowsKeyring) credentialName(key string) string {
 	return k.prefix + ":" + k.name + ":" + key
 }
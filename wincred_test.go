@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/99designs/keyring"
+	"github.com/danieljoos/wincred"
 )
 
 func TestSavingCredentialsWithWinCred(t *testing.T) {
@@ -125,3 +126,92 @@ func TestWinCredKeysWhenEmpty(t *testing.T) {
 		t.Fatalf("Expected 0 keys, got %d", len(keys))
 	}
 }
+
+func TestWinCredPersist(t *testing.T) {
+	kr, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.WinCredBackend},
+		WinCredPersist:  "session",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = kr.Set(keyring.Item{
+		Key:  "test",
+		Data: []byte("loose lips sink ships"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kr.Remove("test")
+
+	cred, err := wincred.GetGenericCredential("keyring:default:test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Persist != wincred.PersistSession {
+		t.Fatalf("Expected session persistence, got %v", cred.Persist)
+	}
+}
+
+func TestWinCredUnknownPersist(t *testing.T) {
+	_, err := keyring.Open(keyring.Config{
+		RequireBackend: keyring.WinCredBackend,
+		WinCredPersist: "forever",
+	})
+	if err == nil {
+		t.Fatal("Expected an error for an unknown persistence")
+	}
+}
+
+func TestWinCredRawTarget(t *testing.T) {
+	kr, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.WinCredBackend},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a credential written by another application, outside the keyring's prefix
+	cred := wincred.NewGenericCredential("llamas-external")
+	cred.CredentialBlob = []byte("loose lips sink ships")
+	cred.Comment = "written by another app"
+	if err := cred.Write(); err != nil {
+		t.Fatal(err)
+	}
+	defer cred.Delete()
+
+	item, err := keyring.GetRawTarget(kr, "llamas-external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "loose lips sink ships" || item.Description != "written by another app" {
+		t.Fatalf("Unexpected item %#v", item)
+	}
+
+	targets, err := keyring.ListAllTargets(kr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, target := range targets {
+		found = found || target == "llamas-external"
+	}
+	if !found {
+		t.Fatalf("Expected llamas-external in %v", targets)
+	}
+
+	keys, err := kr.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if key == "llamas-external" {
+			t.Fatal("Expected Keys to stay scoped to the service")
+		}
+	}
+
+	if _, err := keyring.GetRawTarget(kr, "llamas-missing"); err != keyring.ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}